@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -45,6 +46,19 @@ type SSOCachedCredential struct {
 
 type AWSAccountInfo struct {
 	NormalizedAccountName string
+	Profiles              []string
+	IDProfile             string
+	NameProfile           string
+	Region                string
+	Output                string
+	RoleName              string
+	Aliases               []string
+	Config                map[string]string
+	SteampipeRegions      []string
+	EnabledRegions        []string
+	Steampipe             SteampipePluginOptions
+	RegionsString         string
+	Tags                  []string
 	ssotypes.AccountInfo
 }
 
@@ -64,17 +78,75 @@ type AWSTemplateData struct {
 }
 
 type SteampipeTemplateData struct {
+	Params            *Options
 	Regions           []string
 	AccountList       []AWSAccountInfo
+	Connections       []utils.HCLBlock
 	AllAccountsString string
 	RegionsString     string
 	Marker            string
 }
 
+func awsConfigFilePath() string {
+	return utils.AWSConfigFile()
+}
+
+func steampipeConfigFilePath() string {
+	return filepath.Join(utils.SteampipeConfigDir(), "aws.spc")
+}
+
+func ssoCacheDir() string {
+	return utils.SSOCacheDir()
+}
+
+// Targets returns every file the aws command writes managed blocks to
+func Targets() []string {
+	return []string{awsConfigFilePath(), steampipeConfigFilePath(), utils.WorkspacesFilePath(), flowpipeConfigFilePath(), utils.KubernetesConfigFilePath()}
+}
+
+// PurgeTokenCache removes the SSO access token that aiphelper cached for the start URL. Tokens of other
+// start URLs, such as those cached by the AWS CLI for other organizations, are left alone.
+func PurgeTokenCache(startUrl string) error {
+	cacheFile := ssoCacheFile(startUrl)
+	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	if utils.Settings.DryRun {
+		fmt.Printf("Dry run: would remove cached SSO token %s\n", cacheFile)
+		return nil
+	}
+	fmt.Printf("Removing cached SSO token %s\n", cacheFile)
+	return os.Remove(cacheFile)
+}
+
+// Templates returns the built-in templates keyed by the name used to override them
+func Templates() map[string]string {
+	return map[string]string{
+		"aws/aws_config.tmpl": awsTemplateString,
+		"aws/steampipe.gospc": steampipeTemplateString,
+	}
+}
+
 func Init() {
+	loadOverrides(options.OverridesFile)
+
+	if command.Active != nil {
+		switch command.Active.Name {
+		case "serve-credentials":
+			serveCredentials()
+		case "console":
+			openConsole()
+		}
+		return
+	}
 
-	awsTemplate = template.Must(template.New("awsTemplate").Parse(awsTemplateString))
-	steampipeTemplate = template.Must(template.New("steampipeTemplate").Parse(steampipeTemplateString))
+	if options.AWSConfigOutput == utils.StdoutTarget || options.SteampipeOutput == utils.StdoutTarget {
+		utils.ProgressToStderr()
+	}
+
+	awsTemplate = utils.LoadTemplate("aws/aws_config.tmpl", awsTemplateString)
+	steampipeTemplate = utils.LoadTemplate("aws/steampipe.gospc", steampipeTemplateString)
 
 	awsTemplateData.Params = options
 
@@ -84,10 +156,68 @@ func Init() {
 	}
 
 	// create sso client
-	ssoClient := sso.NewFromConfig(cfg)
+	ssoClient := newSSOClient(cfg)
 	// list accounts
 	fmt.Print("Fetching list of all accounts... ")
 
+	for _, account := range listAccounts(ssoClient, accessToken) {
+		if len(options.Accounts.All) > 0 && !slices.Contains(options.Accounts.All, *account.AccountId) {
+			continue
+		}
+		applyOverrides(&account)
+		accounts = append(accounts, account)
+	}
+
+	fmt.Printf("User has access to %d AWS accounts.\n", len(accounts))
+
+	if needsRegionDiscovery() {
+		discoverRegions(ssoClient, accessToken)
+	}
+
+	fmt.Println("Updating AWS config file with profiles.")
+	if options.AWSConfigOutput == "" {
+		resolveProfileConflicts()
+	}
+	updateAwsConfigFile()
+
+	fmt.Println("Updating Steampipe AWS Plugin config file with connections.")
+	updateSteampipeAwsConfigFile()
+
+	if utils.Settings.Flowpipe {
+		fmt.Println("Updating Flowpipe AWS credentials file.")
+		updateFlowpipeConfigFile()
+	}
+
+	if utils.Settings.Kubernetes {
+		fmt.Println("Updating kubeconfig and Steampipe Kubernetes Plugin config files with EKS clusters.")
+		updateKubernetesConfigFiles(ssoClient, accessToken)
+	}
+
+	fmt.Println("Done.")
+}
+
+// blockName names the managed blocks of this SSO instance so several instances can share a file
+func blockName() string {
+	if options.BlockName != "" {
+		return utils.BlockName("aws", options.BlockName)
+	}
+	instance := options.SSOStartURL
+	if u, err := url.Parse(options.SSOStartURL); err == nil && u.Host != "" {
+		instance = strings.Split(u.Host, ".")[0]
+	}
+	return utils.BlockName("aws", instance)
+}
+
+func newSSOClient(cfg aws.Config) *sso.Client {
+	if options.SSOEndpointURL == "" {
+		return sso.NewFromConfig(cfg)
+	}
+	return sso.NewFromConfig(cfg, sso.WithEndpointResolver(sso.EndpointResolverFromURL(options.SSOEndpointURL)))
+}
+
+func listAccounts(ssoClient *sso.Client, accessToken string) []AWSAccountInfo {
+	var list []AWSAccountInfo
+
 	accountPaginator := sso.NewListAccountsPaginator(ssoClient, &sso.ListAccountsInput{
 		AccessToken: &accessToken,
 	})
@@ -95,27 +225,15 @@ func Init() {
 	for accountPaginator.HasMorePages() {
 		x, err := accountPaginator.NextPage(context.TODO())
 		if err != nil {
-			fmt.Println(err)
+			log.Fatalln(err)
 		}
 		for _, account := range x.AccountList {
 			account := AWSAccountInfo{AccountInfo: account}
-			if len(options.Accounts.All) > 0 && !slices.Contains(options.Accounts.All, *account.AccountId) {
-				continue
-			}
 			account.NormalizedAccountName = utils.SnakeCase(*account.AccountName)
-			accounts = append(accounts, account)
+			list = append(list, account)
 		}
 	}
-
-	fmt.Printf("User has access to %d AWS accounts.\n", len(accounts))
-
-	fmt.Println("Updating AWS config file with profiles.")
-	updateAwsConfigFile()
-
-	fmt.Println("Updating Steampipe AWS Plugin config file with connections.")
-	updateSteampipeAwsConfigFile()
-
-	fmt.Println("Done.")
+	return list
 }
 
 func authenticate() (string, aws.Config, error) {
@@ -218,10 +336,56 @@ func authenticate() (string, aws.Config, error) {
 	return accessToken, cfg, nil
 }
 
+// resolveProfileConflicts applies the conflict policy to generated profiles that are also defined outside of the aiphelper block
+func resolveProfileConflicts() {
+	awsConfigFilePath := awsConfigFilePath()
+
+	unmanaged, err := utils.ReadUnmanaged(awsConfigFilePath, blockName())
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	existing := map[string]bool{}
+	for _, section := range utils.IniSections(unmanaged) {
+		existing[section] = true
+	}
+
+	for i := range accounts {
+		account := &accounts[i]
+		var profiles []string
+		for _, profile := range account.Profiles {
+			if !existing["profile "+profile] {
+				profiles = append(profiles, profile)
+				continue
+			}
+
+			switch utils.Settings.ConflictPolicy {
+			case utils.ConflictFail:
+				log.Fatalf("profile %s for account %s is already defined outside the aiphelper block in %s", profile, *account.AccountId, awsConfigFilePath)
+			case utils.ConflictSkip:
+				fmt.Printf("Warning: skipping generated profile %s, which is already defined in %s\n", profile, awsConfigFilePath)
+			case utils.ConflictRename:
+				renamed := profile + utils.RenameSuffix
+				fmt.Printf("Warning: profile %s is already defined in %s, generating %s instead\n", profile, awsConfigFilePath, renamed)
+				if account.IDProfile == profile {
+					account.IDProfile = renamed
+				}
+				if account.NameProfile == profile {
+					account.NameProfile = renamed
+				}
+				profiles = append(profiles, renamed)
+			default:
+				fmt.Printf("Warning: profile %s is also defined outside the aiphelper block in %s\n", profile, awsConfigFilePath)
+				profiles = append(profiles, profile)
+			}
+		}
+		account.Profiles = profiles
+	}
+}
+
 func updateAwsConfigFile() {
 	var err error = nil
-	homeDir, _ := os.UserHomeDir()
-	awsConfigFilePath := filepath.Join(homeDir, ".aws/config")
+	awsConfigFilePath := awsConfigFilePath()
 
 	var awsTemplateBuffer bytes.Buffer
 
@@ -231,7 +395,7 @@ func updateAwsConfigFile() {
 		log.Fatalln(err)
 	}
 
-	err = utils.CreateOrReplaceInFile(awsConfigFilePath, awsTemplateBuffer.String())
+	err = utils.WriteOutput(options.AWSConfigOutput, awsConfigFilePath, blockName(), awsTemplateBuffer.String())
 
 	if err != nil {
 		log.Fatalln(err)
@@ -239,36 +403,64 @@ func updateAwsConfigFile() {
 }
 
 func updateSteampipeAwsConfigFile() {
-	var spcTemplateBuffer bytes.Buffer
-	var err error = nil
+	var section string
 
-	steampipeTemplateData.AccountList = accounts
+	var allAccounts []string
+	for i, account := range accounts {
+		accounts[i].RegionsString = utils.HCLJoinInner(nonEmpty(account.SteampipeRegions))
+		allAccounts = append(allAccounts, aggregatedConnection(account))
+	}
 
-	steampipeTemplateData.RegionsString = strings.Join(options.Regions.All, "\", \"")
+	steampipeTemplateData.Params = options
+	steampipeTemplateData.AccountList = accounts
+	steampipeTemplateData.Connections = steampipeConnections()
+	if err := checkConnectionCount(steampipeTemplateData.Connections); err != nil {
+		log.Fatalln(err)
+	}
+	steampipeTemplateData.Regions = steampipeRegions()
+	steampipeTemplateData.RegionsString = utils.HCLJoinInner(steampipeTemplateData.Regions)
+	steampipeTemplateData.AllAccountsString = utils.HCLJoin(allAccounts)
 
-	for _, account := range accounts {
-		steampipeTemplateData.AllAccountsString = steampipeTemplateData.AllAccountsString + "\"aws_" + *account.AccountId + "\", "
+	if utils.HasTemplateOverride("aws/steampipe.gospc") {
+		var spcTemplateBuffer bytes.Buffer
+		err := steampipeTemplate.Execute(&spcTemplateBuffer, steampipeTemplateData)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		section = spcTemplateBuffer.String()
+	} else {
+		section = "\n" + utils.RenderHCL(steampipeTemplateData.Connections)
 	}
 
-	steampipeTemplateData.AllAccountsString = strings.Trim(steampipeTemplateData.AllAccountsString, ", ")
+	spcFilePath := steampipeConfigFilePath()
 
-	err = steampipeTemplate.Execute(&spcTemplateBuffer, steampipeTemplateData)
+	err := utils.ValidateSteampipeSection(spcFilePath, section, steampipeTemplateData.Connections)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	spcFilePath := filepath.Join(homeDir, ".steampipe/config/aws.spc")
+	if options.SteampipeOutput == "" {
+		err = utils.CheckSteampipeConflicts(spcFilePath, blockName(), section)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
 
-	err = utils.CreateOrReplaceInFile(spcFilePath, spcTemplateBuffer.String())
+	err = utils.WriteOutput(options.SteampipeOutput, spcFilePath, blockName(), section)
 	if err != nil {
 		log.Fatalln(err)
 	}
+
+	if options.SteampipeOutput == "" {
+		err = utils.UpdateSteampipeSettings(spcFilePath, blockName(), steampipeTemplateData.Connections)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
 }
 
 func searchForSsoCachedCredentials(startUrl string, region string) (string, error) {
-	homedir, _ := os.UserHomeDir()
-	globPattern := filepath.Join(homedir, ".aws/sso/cache", "*.json")
+	globPattern := filepath.Join(ssoCacheDir(), "*.json")
 	matches, err := filepath.Glob(globPattern)
 	if err != nil {
 		log.Fatalf("Failed to match %q: %v", globPattern, err)
@@ -302,14 +494,17 @@ func searchForSsoCachedCredentials(startUrl string, region string) (string, erro
 	return "", errors.New("No access token found")
 }
 
-func putSsoCachedCredentials(creds SSOCachedCredential) error {
-	s := creds.StartUrl
+// ssoCacheFile returns the file the access token of a start URL is cached in, which is named after the SHA-1 of the start URL
+func ssoCacheFile(startUrl string) string {
 	h := sha1.New()
-	h.Write([]byte(s))
+	h.Write([]byte(startUrl))
 	hash := hex.EncodeToString(h.Sum(nil))
 
-	homedir, _ := os.UserHomeDir()
-	cacheFile := filepath.Join(homedir, ".aws/sso/cache", fmt.Sprintf("%s.json", hash))
+	return filepath.Join(ssoCacheDir(), fmt.Sprintf("%s.json", hash))
+}
+
+func putSsoCachedCredentials(creds SSOCachedCredential) error {
+	cacheFile := ssoCacheFile(creds.StartUrl)
 
 	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
 		os.MkdirAll(filepath.Dir(cacheFile), 0755)
@@ -17,23 +17,67 @@ type Accounts struct {
 	All []string
 }
 
+type ErrorCodes struct {
+	All []string
+}
+
 var (
-	options *Options
+	options                 *Options
+	command                 *flags.Command
+	serveCredentialsOptions *ServeCredentialsOptions
+	consoleOptions          *ConsoleOptions
 )
 
 type Options struct {
-	SSOStartURL   string    `long:"sso-start-url" default:"https://aggie-innovation-platform.awsapps.com/start" description:"AWS SSO Start URL"`
-	SSORegion     string    `long:"sso-region" default:"us-east-2" description:"AWS SSO Region"`
-	SSORoleName   string    `long:"sso-role-name" default:"AdministratorAccess" description:"SSO Role To Assume (must be the same across all accounts)"`
-	Regions       Regions   `long:"regions" default:"" description:"Comma-separated list of regions to tell Steampipe to connect to (default: uses same search order as aws cli)"`
-	Accounts      *Accounts `long:"accounts" default:"" description:"Comma-separated list of accounts to tell Steampipe to connect to (default: all accounts assigned to you through SSO)"`
-	DefaultFormat string    `long:"output-format" default:"json" description:"Output format for AWS CLI"`
-	DefaultRegion string    `long:"default-region" default:"us-east-1" description:"Default region for AWS CLI operations"`
+	SSOStartURL     string    `long:"sso-start-url" default:"https://aggie-innovation-platform.awsapps.com/start" description:"AWS SSO Start URL"`
+	SSORegion       string    `long:"sso-region" default:"us-east-2" description:"AWS SSO Region"`
+	SSORoleName     string    `long:"sso-role-name" default:"AdministratorAccess" description:"SSO Role To Assume (must be the same across all accounts)"`
+	Regions         Regions   `long:"regions" default:"" description:"Comma-separated list of regions to tell Steampipe to connect to, or auto to discover the enabled regions of each account (default: uses same search order as aws cli)"`
+	Accounts        *Accounts `long:"accounts" default:"" description:"Comma-separated list of accounts to tell Steampipe to connect to (default: all accounts assigned to you through SSO)"`
+	DefaultFormat   string    `long:"output-format" default:"json" description:"Output format for AWS CLI"`
+	DefaultRegion   string    `long:"default-region" default:"us-east-1" description:"Default region for AWS CLI operations"`
+	OverridesFile   string    `long:"overrides-file" description:"YAML file with per-account overrides for region, output format, role name, aliases, extra config keys, Steampipe regions and plugin options"`
+	BlockName       string    `long:"block-name" description:"Name of the managed blocks written for this SSO instance (default: derived from the SSO start URL)"`
+	AWSConfigOutput string    `long:"aws-config-output" description:"Write the AWS config block to - (stdout) or to a standalone file instead of the AWS config file"`
+	SteampipeOutput string    `long:"steampipe-output" description:"Write the Steampipe connections block to - (stdout) or to a standalone file instead of aws.spc"`
+	SSOEndpointURL  string    `long:"sso-endpoint-url" description:"Override the AWS SSO portal endpoint (default: the regional AWS endpoint)"`
+
+	SteampipeConnections           string      `long:"steampipe-connections" default:"both" choice:"id" choice:"name" choice:"both" description:"Which Steampipe connections to generate for each account: aws_<accountid>, aws_<normalizedname> or both"`
+	SteampipePerRegion             bool        `long:"steampipe-per-region" description:"Also generate a Steampipe connection for each account and region, and an aws_all_<region> aggregator for each region"`
+	SteampipeMaxConnections        int         `long:"steampipe-max-connections" default:"500" description:"Maximum number of Steampipe connections --steampipe-per-region may generate (0 for no limit)"`
+	SteampipePlugin                string      `long:"steampipe-plugin" default:"aws" description:"Steampipe plugin of the generated connections, optionally pinned to a version such as aws@^0.80"`
+	SteampipeIgnoreErrorCodes      *ErrorCodes `long:"steampipe-ignore-error-codes" default:"" description:"Comma-separated list of AWS error codes for Steampipe to ignore, such as AccessDenied,UnauthorizedOperation"`
+	SteampipeMaxErrorRetryAttempts int         `long:"steampipe-max-error-retry-attempts" description:"Maximum number of retries of throttled or failed AWS API calls (default: plugin default)"`
+	SteampipeMinErrorRetryDelay    int         `long:"steampipe-min-error-retry-delay" description:"Minimum delay in milliseconds between retries (default: plugin default)"`
+	SteampipeDefaultRegion         string      `long:"steampipe-default-region" description:"Region Steampipe uses for global services (default: plugin default)"`
+	SteampipeEndpointURL           string      `long:"steampipe-endpoint-url" description:"Custom AWS API endpoint for Steampipe, such as a LocalStack URL"`
+}
+
+type ServeCredentialsOptions struct {
+	Profile            string `long:"profile" required:"true" description:"Profile to serve credentials for (normalized account name or account ID)"`
+	Listen             string `long:"listen" default:"127.0.0.1:9911" description:"Address for the credentials server to listen on"`
+	AuthorizationToken string `long:"authorization-token" description:"Token clients must send in the Authorization header (default: randomly generated)"`
+}
+
+type ConsoleOptions struct {
+	Profile            string `long:"profile" required:"true" description:"Profile to open the console for (normalized account name or account ID)"`
+	Service            string `long:"service" description:"Console service to open, such as ec2 (default: console home)"`
+	Print              bool   `long:"print" description:"Print the sign-in URL instead of opening it in a browser"`
+	FederationEndpoint string `long:"federation-endpoint" default:"https://signin.aws.amazon.com/federation" description:"AWS federation endpoint used to create the sign-in URL"`
 }
 
 func AddCommand(p *flags.Parser) {
 	options = &Options{}
-	p.AddCommand("aws", "Initialize AWS", "Initialize AWS", options)
+	command, _ = p.AddCommand("aws", "Initialize AWS", "Initialize AWS", options)
+	command.SubcommandsOptional = true
+
+	serveCredentialsOptions = &ServeCredentialsOptions{}
+	command.AddCommand("serve-credentials", "Serve container credentials for a profile",
+		"Serve AWS credentials for a profile over the container credentials provider protocol (AWS_CONTAINER_CREDENTIALS_FULL_URI)", serveCredentialsOptions)
+
+	consoleOptions = &ConsoleOptions{}
+	command.AddCommand("console", "Open the AWS console for a profile",
+		"Open a federated AWS console sign-in URL for a profile", consoleOptions)
 }
 
 func (r *Regions) UnmarshalFlag(arg string) error {
@@ -61,3 +105,12 @@ func (a *Accounts) UnmarshalFlag(arg string) error {
 	a.All = tempValue
 	return nil
 }
+
+func (e *ErrorCodes) UnmarshalFlag(arg string) error {
+	if arg == "" {
+		e.All = []string{}
+		return nil
+	}
+	e.All = utils.SplitArgumentParser(arg)
+	return nil
+}
@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/pkg/browser"
+)
+
+type federationSession struct {
+	SessionID    string `json:"sessionId"`
+	SessionKey   string `json:"sessionKey"`
+	SessionToken string `json:"sessionToken"`
+}
+
+type federationSigninToken struct {
+	SigninToken string
+}
+
+func openConsole() {
+	accessToken, cfg, err := authenticate()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ssoClient := newSSOClient(cfg)
+
+	account, err := findAccount(ssoClient, accessToken, consoleOptions.Profile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	roleCredentials, err := getRoleCredentials(ssoClient, accessToken, *account.AccountId, account.RoleName)
+	if err != nil {
+		log.Fatalf("failed to get role credentials: %v", err)
+	}
+
+	signinToken, err := getSigninToken(federationSession{
+		SessionID:    aws.ToString(roleCredentials.AccessKeyId),
+		SessionKey:   aws.ToString(roleCredentials.SecretAccessKey),
+		SessionToken: aws.ToString(roleCredentials.SessionToken),
+	})
+	if err != nil {
+		log.Fatalf("failed to get console sign-in token: %v", err)
+	}
+
+	loginURL := consoleLoginURL(signinToken, account.Region)
+
+	if consoleOptions.Print {
+		fmt.Println(loginURL)
+		return
+	}
+
+	fmt.Printf("Opening console for %s (%s). If browser is not opened automatically, please open link:\n%v\n", *account.AccountName, *account.AccountId, loginURL)
+	if err := browser.OpenURL(loginURL); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// getSigninToken exchanges role credentials for a console sign-in token at the federation endpoint
+func getSigninToken(session federationSession) (string, error) {
+	sessionJSON, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("Action", "getSigninToken")
+	query.Set("Session", string(sessionJSON))
+
+	resp, err := http.Get(consoleOptions.FederationEndpoint + "?" + query.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("federation endpoint returned %s", resp.Status)
+	}
+
+	var token federationSigninToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.SigninToken == "" {
+		return "", fmt.Errorf("federation endpoint returned no sign-in token")
+	}
+	return token.SigninToken, nil
+}
+
+// consoleLoginURL builds the sign-in URL, opening --service in the account's region
+func consoleLoginURL(signinToken string, region string) string {
+	destination := "https://console.aws.amazon.com/"
+	if consoleOptions.Service != "" {
+		destination = fmt.Sprintf("https://console.aws.amazon.com/%s/home?region=%s", url.PathEscape(consoleOptions.Service), url.QueryEscape(region))
+	}
+
+	query := url.Values{}
+	query.Set("Action", "login")
+	query.Set("Issuer", "github.com/tamu-edu/aiphelper")
+	query.Set("Destination", destination)
+	query.Set("SigninToken", signinToken)
+
+	return consoleOptions.FederationEndpoint + "?" + query.Encode()
+}
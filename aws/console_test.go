@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newFakeFederation starts a fake federation endpoint that hands out a sign-in token for the test session
+func newFakeFederation(t *testing.T, status int) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var session federationSession
+		if r.URL.Query().Get("Action") != "getSigninToken" || json.Unmarshal([]byte(r.URL.Query().Get("Session")), &session) != nil ||
+			session != (federationSession{SessionID: "AKIAFAKE", SessionKey: "secret", SessionToken: "session"}) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(federationSigninToken{SigninToken: "signin-token"})
+	}))
+	t.Cleanup(server.Close)
+
+	previous := consoleOptions
+	consoleOptions = &ConsoleOptions{FederationEndpoint: server.URL + "/federation", Service: "ec2"}
+	t.Cleanup(func() { consoleOptions = previous })
+}
+
+func TestGetSigninToken(t *testing.T) {
+	session := federationSession{SessionID: "AKIAFAKE", SessionKey: "secret", SessionToken: "session"}
+
+	newFakeFederation(t, http.StatusOK)
+	token, err := getSigninToken(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "signin-token" {
+		t.Errorf("getSigninToken() = %q, want signin-token", token)
+	}
+
+	if _, err := getSigninToken(federationSession{SessionID: "other"}); err == nil {
+		t.Error("getSigninToken accepted a 400 from the federation endpoint")
+	}
+
+	newFakeFederation(t, http.StatusForbidden)
+	if _, err := getSigninToken(session); err == nil {
+		t.Error("getSigninToken accepted a 403 from the federation endpoint")
+	}
+}
+
+func TestConsoleLoginURL(t *testing.T) {
+	newFakeFederation(t, http.StatusOK)
+
+	previous := options
+	options = &Options{DefaultRegion: "us-east-1", SteampipeIgnoreErrorCodes: &ErrorCodes{}}
+	t.Cleanup(func() { options = previous })
+
+	loginURL, err := url.Parse(consoleLoginURL("signin-token", "eu-west-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loginURL.Scheme+"://"+loginURL.Host+loginURL.Path, consoleOptions.FederationEndpoint; got != want {
+		t.Errorf("login URL points at %s, want %s", got, want)
+	}
+	query := loginURL.Query()
+	if query.Get("Action") != "login" || query.Get("SigninToken") != "signin-token" {
+		t.Errorf("login URL has query %v", query)
+	}
+	if got, want := query.Get("Destination"), "https://console.aws.amazon.com/ec2/home?region=eu-west-1"; got != want {
+		t.Errorf("login URL destination is %s, want the account's region in %s", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+package aws
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+// Credentials are refreshed this long before they expire
+const credentialsRefreshWindow = 5 * time.Minute
+
+// errSSOSessionExpired is returned when SSO no longer accepts the access token
+var errSSOSessionExpired = errors.New("the AWS SSO session has expired; re-run aiphelper aws to sign in")
+
+// ContainerCredentials is the document returned by the container credentials provider protocol
+type ContainerCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+	Expiration      time.Time
+}
+
+type credentialsServer struct {
+	ssoClient   *sso.Client
+	accessToken string
+	account     AWSAccountInfo
+	authToken   string
+
+	mu          sync.Mutex
+	credentials *ContainerCredentials
+}
+
+func serveCredentials() {
+	accessToken, cfg, err := authenticate()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ssoClient := newSSOClient(cfg)
+
+	account, err := findAccount(ssoClient, accessToken, serveCredentialsOptions.Profile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	authToken := serveCredentialsOptions.AuthorizationToken
+	if authToken == "" {
+		authToken = randomToken()
+	}
+
+	server := &credentialsServer{
+		ssoClient:   ssoClient,
+		accessToken: accessToken,
+		account:     account,
+		authToken:   authToken,
+	}
+
+	if _, err := server.refresh(); err != nil {
+		log.Fatalln(err)
+	}
+	go server.refreshLoop()
+
+	fmt.Printf("Serving credentials for %s (%s) with role %s on %s\n", *account.AccountName, *account.AccountId, account.RoleName, serveCredentialsOptions.Listen)
+	fmt.Println("Set the following environment variables in your containers:")
+	fmt.Printf("AWS_CONTAINER_CREDENTIALS_FULL_URI=http://%s/\n", serveCredentialsOptions.Listen)
+	fmt.Printf("AWS_CONTAINER_AUTHORIZATION_TOKEN=%s\n", authToken)
+
+	log.Fatalln(http.ListenAndServe(serveCredentialsOptions.Listen, server))
+}
+
+// findAccount looks up an account by any of its profile names: the normalized account name, the account ID
+// or an alias from the overrides file. The account's overrides are applied, so it uses its own role.
+func findAccount(ssoClient *sso.Client, accessToken string, profile string) (AWSAccountInfo, error) {
+	for _, account := range listAccounts(ssoClient, accessToken) {
+		applyOverrides(&account)
+		if slices.Contains(account.Profiles, profile) {
+			return account, nil
+		}
+	}
+	return AWSAccountInfo{}, fmt.Errorf("no account matching profile %q is assigned to you through SSO", profile)
+}
+
+func getRoleCredentials(ssoClient *sso.Client, accessToken string, accountID string, roleName string) (*ssotypes.RoleCredentials, error) {
+	output, err := ssoClient.GetRoleCredentials(context.TODO(), &sso.GetRoleCredentialsInput{
+		AccessToken: aws.String(accessToken),
+		AccountId:   aws.String(accountID),
+		RoleName:    aws.String(roleName),
+	})
+	var unauthorized *ssotypes.UnauthorizedException
+	if errors.As(err, &unauthorized) {
+		return nil, errSSOSessionExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	if output.RoleCredentials == nil || output.RoleCredentials.AccessKeyId == nil {
+		return nil, errors.New("SSO returned no role credentials")
+	}
+	return output.RoleCredentials, nil
+}
+
+func (s *credentialsServer) refresh() (*ContainerCredentials, error) {
+	roleCredentials, err := getRoleCredentials(s.ssoClient, s.accessToken, *s.account.AccountId, s.account.RoleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role credentials: %w", err)
+	}
+
+	credentials := &ContainerCredentials{
+		AccessKeyId:     aws.ToString(roleCredentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(roleCredentials.SecretAccessKey),
+		Token:           aws.ToString(roleCredentials.SessionToken),
+		Expiration:      time.UnixMilli(roleCredentials.Expiration).UTC(),
+	}
+
+	s.mu.Lock()
+	s.credentials = credentials
+	s.mu.Unlock()
+
+	return credentials, nil
+}
+
+// refreshLoop renews the role credentials shortly before they expire. The server exits once the SSO
+// session has expired, since the credentials cannot be renewed without signing in again.
+func (s *credentialsServer) refreshLoop() {
+	for {
+		s.mu.Lock()
+		wait := time.Until(s.credentials.Expiration) - credentialsRefreshWindow
+		s.mu.Unlock()
+
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+		if _, err := s.refresh(); err != nil {
+			if errors.Is(err, errSSOSessionExpired) {
+				log.Fatalln(err)
+			}
+			log.Println(err)
+			time.Sleep(30 * time.Second)
+		}
+	}
+}
+
+func (s *credentialsServer) current() (*ContainerCredentials, error) {
+	s.mu.Lock()
+	credentials := s.credentials
+	s.mu.Unlock()
+
+	if time.Until(credentials.Expiration) > credentialsRefreshWindow {
+		return credentials, nil
+	}
+	return s.refresh()
+}
+
+func (s *credentialsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Compare in constant time so the token cannot be guessed from response times
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(s.authToken)) != 1 {
+		http.Error(w, "invalid authorization token", http.StatusUnauthorized)
+		return
+	}
+
+	credentials, err := s.current()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(credentials)
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalln(err)
+	}
+	return hex.EncodeToString(b)
+}
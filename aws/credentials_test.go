@@ -0,0 +1,245 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+)
+
+const fakeAccessToken = "fake-access-token"
+
+// fakeSSO serves the ListAccounts and GetRoleCredentials operations of the SSO portal API
+type fakeSSO struct {
+	mu          sync.Mutex
+	expiration  time.Duration
+	expired     bool
+	roleCalls   int
+	lastRole    string
+	lastAccount string
+}
+
+func (f *fakeSSO) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.expired || r.Header.Get("x-amz-sso_bearer_token") != fakeAccessToken {
+		w.Header().Set("X-Amzn-ErrorType", "UnauthorizedException")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Session token not found or invalid"}`))
+		return
+	}
+
+	var body interface{}
+	switch r.URL.Path {
+	case "/assignment/accounts":
+		body = map[string]interface{}{
+			"accountList": []map[string]string{
+				{"accountId": "111111111111", "accountName": "Dept Prod Account", "emailAddress": "prod@example.edu"},
+				{"accountId": "222222222222", "accountName": "Dept Dev", "emailAddress": "dev@example.edu"},
+			},
+		}
+	case "/federation/credentials":
+		f.roleCalls++
+		f.lastRole = r.URL.Query().Get("role_name")
+		f.lastAccount = r.URL.Query().Get("account_id")
+		body = map[string]interface{}{
+			"roleCredentials": map[string]interface{}{
+				"accessKeyId":     "AKIAFAKE",
+				"secretAccessKey": "secret",
+				"sessionToken":    "session",
+				"expiration":      time.Now().Add(f.expiration).UnixMilli(),
+			},
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (f *fakeSSO) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.roleCalls
+}
+
+// newFakeSSO starts a fake SSO endpoint and points the aws command's options at it
+func newFakeSSO(t *testing.T) (*fakeSSO, *sso.Client) {
+	fake := &fakeSSO{expiration: time.Hour}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	previous := options
+	options = &Options{SSORoleName: "AdministratorAccess", SSORegion: "us-east-2", SSOEndpointURL: server.URL, SteampipeIgnoreErrorCodes: &ErrorCodes{}}
+	t.Cleanup(func() { options = previous })
+
+	return fake, newSSOClient(aws.Config{Region: options.SSORegion})
+}
+
+func TestFindAccount(t *testing.T) {
+	_, ssoClient := newFakeSSO(t)
+
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{"dept_prod_account", "111111111111"},
+		{"111111111111", "111111111111"},
+		{"222222222222", "222222222222"},
+		{"dept_dev", "222222222222"},
+	}
+	for _, test := range tests {
+		account, err := findAccount(ssoClient, fakeAccessToken, test.profile)
+		if err != nil {
+			t.Errorf("findAccount(%q): %v", test.profile, err)
+			continue
+		}
+		if *account.AccountId != test.want {
+			t.Errorf("findAccount(%q) = %s, want %s", test.profile, *account.AccountId, test.want)
+		}
+	}
+
+	if _, err := findAccount(ssoClient, fakeAccessToken, "unknown"); err == nil {
+		t.Error("findAccount(unknown) did not fail")
+	}
+}
+
+func TestFindAccountOverrides(t *testing.T) {
+	fake, ssoClient := newFakeSSO(t)
+
+	previous := overrides
+	overrides = Overrides{Accounts: AccountOverrideList{
+		{Match: "Dept Prod*", RoleName: "ReadOnlyAccess", Aliases: []string{"prod"}},
+	}}
+	t.Cleanup(func() { overrides = previous })
+
+	account, err := findAccount(ssoClient, fakeAccessToken, "prod")
+	if err != nil {
+		t.Fatalf("findAccount(prod): %v", err)
+	}
+	if *account.AccountId != "111111111111" {
+		t.Errorf("findAccount(prod) = %s, want 111111111111", *account.AccountId)
+	}
+
+	server := &credentialsServer{ssoClient: ssoClient, accessToken: fakeAccessToken, account: account}
+	if _, err := server.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if fake.lastRole != "ReadOnlyAccess" {
+		t.Errorf("credentials requested for role %q, want the overridden ReadOnlyAccess", fake.lastRole)
+	}
+
+	account, err = findAccount(ssoClient, fakeAccessToken, "dept_dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.RoleName != "AdministratorAccess" {
+		t.Errorf("account without overrides has role %q, want AdministratorAccess", account.RoleName)
+	}
+}
+
+func newTestCredentialsServer(t *testing.T, ssoClient *sso.Client) *credentialsServer {
+	account, err := findAccount(ssoClient, fakeAccessToken, "dept_prod_account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &credentialsServer{ssoClient: ssoClient, accessToken: fakeAccessToken, account: account, authToken: "secret-token"}
+	if _, err := server.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestServeHTTP(t *testing.T) {
+	_, ssoClient := newFakeSSO(t)
+	server := newTestCredentialsServer(t, ssoClient)
+
+	for _, token := range []string{"", "wrong-token"} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if token != "" {
+			request.Header.Set("Authorization", token)
+		}
+		server.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("authorization %q: status %d, want %d", token, recorder.Code, http.StatusUnauthorized)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Authorization", "secret-token")
+	server.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type %q, want application/json", contentType)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"AccessKeyId": "AKIAFAKE", "SecretAccessKey": "secret", "Token": "session"}
+	for key, value := range want {
+		if document[key] != value {
+			t.Errorf("%s = %v, want %s", key, document[key], value)
+		}
+	}
+	expiration, err := time.Parse(time.RFC3339, document["Expiration"].(string))
+	if err != nil {
+		t.Fatalf("Expiration %v is not RFC 3339: %v", document["Expiration"], err)
+	}
+	if time.Until(expiration) < 50*time.Minute {
+		t.Errorf("Expiration %s is not an hour from now", expiration)
+	}
+}
+
+func TestCurrentRefreshesInsideWindow(t *testing.T) {
+	fake, ssoClient := newFakeSSO(t)
+	fake.expiration = credentialsRefreshWindow / 2
+	server := newTestCredentialsServer(t, ssoClient)
+
+	if _, err := server.current(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.calls(); calls != 2 {
+		t.Errorf("credentials expiring inside the refresh window: %d GetRoleCredentials calls, want 2", calls)
+	}
+
+	fake.mu.Lock()
+	fake.expiration = time.Hour
+	fake.mu.Unlock()
+	if _, err := server.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.current(); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.calls(); calls != 3 {
+		t.Errorf("credentials outside the refresh window: %d GetRoleCredentials calls, want 3", calls)
+	}
+}
+
+func TestRefreshExpiredSession(t *testing.T) {
+	fake, ssoClient := newFakeSSO(t)
+	server := newTestCredentialsServer(t, ssoClient)
+
+	fake.mu.Lock()
+	fake.expired = true
+	fake.mu.Unlock()
+
+	if _, err := server.refresh(); !errors.Is(err, errSSOSessionExpired) {
+		t.Errorf("refresh with an expired SSO session returned %v, want %v", err, errSSOSessionExpired)
+	}
+}
@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+func flowpipeConfigFilePath() string {
+	return filepath.Join(utils.FlowpipeConfigDir(), "aws.fpc")
+}
+
+// flowpipeCredentials builds a Flowpipe credential for each Steampipe connection of an account, with the same names
+func flowpipeCredentials() []utils.HCLBlock {
+	var credentials []utils.HCLBlock
+	for _, account := range accounts {
+		var accountCredentials []utils.HCLBlock
+		if options.SteampipeConnections != ConnectionsByName {
+			accountCredentials = append(accountCredentials, flowpipeCredential("aws_"+*account.AccountId, account.IDProfile))
+		}
+		if options.SteampipeConnections != ConnectionsByID {
+			accountCredentials = append(accountCredentials, flowpipeCredential("aws_"+account.NormalizedAccountName, account.NameProfile))
+		}
+
+		accountCredentials[0].Comments = []string{
+			fmt.Sprintf("Account Name: %s", *account.AccountName),
+			fmt.Sprintf("Account Email: %s", *account.EmailAddress),
+		}
+		for i := range accountCredentials {
+			accountCredentials[i].Source = fmt.Sprintf("account %q (%s)", *account.AccountName, *account.AccountId)
+		}
+		credentials = append(credentials, accountCredentials...)
+	}
+	return credentials
+}
+
+func flowpipeCredential(name string, profile string) utils.HCLBlock {
+	return utils.HCLBlock{
+		Type:   "credential",
+		Labels: []string{"aws", name},
+		Attributes: []utils.HCLAttribute{
+			utils.StringAttribute("profile", profile),
+		},
+	}
+}
+
+func updateFlowpipeConfigFile() {
+	credentials := flowpipeCredentials()
+	section := "\n" + utils.RenderHCL(credentials)
+
+	fpcFilePath := flowpipeConfigFilePath()
+
+	err := utils.ValidateFlowpipeSection(fpcFilePath, section, credentials)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = utils.CreateOrReplaceInFile(fpcFilePath, blockName(), section)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
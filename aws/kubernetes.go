@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+// eksCluster is an EKS cluster of an account
+type eksCluster struct {
+	Name                     string
+	Region                   string
+	Arn                      string
+	Endpoint                 string
+	CertificateAuthorityData string
+}
+
+// kubernetesBlockName names the managed blocks of the EKS clusters. It starts like blockName so
+// aiphelper clean --provider aws removes them too.
+func kubernetesBlockName() string {
+	return blockName() + ":eks"
+}
+
+// kubernetesRegions returns the regions to look for clusters in: the enabled regions when they were
+// discovered, otherwise the account's Steampipe regions without wildcards, or its default region
+func kubernetesRegions(account AWSAccountInfo) []string {
+	if len(account.EnabledRegions) > 0 {
+		return account.EnabledRegions
+	}
+	regions := exactRegions(account.SteampipeRegions)
+	if len(regions) == 0 {
+		return []string{account.Region}
+	}
+	return regions
+}
+
+// discoverClusters lists the EKS clusters of every account. Accounts or regions whose clusters cannot be
+// listed are skipped with a warning.
+func discoverClusters(ssoClient *sso.Client, accessToken string) [][]eksCluster {
+	fmt.Printf("Discovering EKS clusters of %d accounts... ", len(accounts))
+
+	var wg sync.WaitGroup
+	clusters := make([][]eksCluster, len(accounts))
+	errs := make([][]error, len(accounts))
+	limit := make(chan struct{}, regionDiscoveryConcurrency)
+
+	for i := range accounts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			clusters[i], errs[i] = eksClusters(ssoClient, accessToken, accounts[i])
+		}(i)
+	}
+	wg.Wait()
+
+	fmt.Println("done.")
+
+	for i, account := range accounts {
+		for _, err := range errs[i] {
+			fmt.Printf("Warning: could not list the EKS clusters of %s (%s): %v\n", *account.AccountName, *account.AccountId, err)
+		}
+	}
+	return clusters
+}
+
+// eksClusters lists the clusters of an account in its Kubernetes regions with the account's role credentials.
+// A region whose clusters cannot be listed, such as one the account's role may not use, is skipped and its
+// error returned along with the clusters of the other regions.
+func eksClusters(ssoClient *sso.Client, accessToken string, account AWSAccountInfo) ([]eksCluster, []error) {
+	roleCredentials, err := getRoleCredentials(ssoClient, accessToken, *account.AccountId, account.RoleName)
+	if err != nil {
+		return nil, []error{err}
+	}
+	provider := credentials.NewStaticCredentialsProvider(
+		aws.ToString(roleCredentials.AccessKeyId),
+		aws.ToString(roleCredentials.SecretAccessKey),
+		aws.ToString(roleCredentials.SessionToken),
+	)
+
+	var clusters []eksCluster
+	var errs []error
+	for _, region := range kubernetesRegions(account) {
+		found, err := regionClusters(eks.New(eks.Options{Region: region, Credentials: provider}), region)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", region, err))
+			continue
+		}
+		clusters = append(clusters, found...)
+	}
+	return clusters, errs
+}
+
+// regionClusters lists and describes the clusters of one region
+func regionClusters(eksClient *eks.Client, region string) ([]eksCluster, error) {
+	var names []string
+	paginator := eks.NewListClustersPaginator(eksClient, &eks.ListClustersInput{})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, output.Clusters...)
+	}
+	sort.Strings(names)
+
+	var clusters []eksCluster
+	for _, name := range names {
+		output, err := eksClient.DescribeCluster(context.TODO(), &eks.DescribeClusterInput{Name: aws.String(name)})
+		if err != nil {
+			return nil, err
+		}
+		cluster := eksCluster{
+			Name:     name,
+			Region:   region,
+			Arn:      aws.ToString(output.Cluster.Arn),
+			Endpoint: aws.ToString(output.Cluster.Endpoint),
+		}
+		if output.Cluster.CertificateAuthority != nil {
+			cluster.CertificateAuthorityData = aws.ToString(output.Cluster.CertificateAuthority.Data)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// kubernetesClusters builds a kubeconfig context and Steampipe connection for each cluster, such as
+// kubernetes_aws_123456789012_my_cluster. Clusters of an account with the same name in several regions
+// get the region appended, and names longer than Steampipe allows are shortened. Tokens come from aws eks
+// get-token with the account's profile.
+func kubernetesClusters(clusters [][]eksCluster) []utils.KubernetesCluster {
+	var kubernetesClusters []utils.KubernetesCluster
+	for i, account := range accounts {
+		names := map[string]int{}
+		for _, cluster := range clusters[i] {
+			names[cluster.Name]++
+		}
+
+		for _, cluster := range clusters[i] {
+			connection := "kubernetes_" + aggregatedConnection(account) + "_" + utils.SnakeCase(cluster.Name)
+			if names[cluster.Name] > 1 {
+				connection += "_" + utils.SnakeCase(cluster.Region)
+			}
+			connection = utils.ShortenConnectionName(connection)
+
+			kubernetesClusters = append(kubernetesClusters, utils.KubernetesCluster{
+				Connection: connection,
+				Comments: []string{
+					fmt.Sprintf("Account Name: %s", *account.AccountName),
+					fmt.Sprintf("Cluster: %s", cluster.Arn),
+				},
+				Source: fmt.Sprintf("EKS cluster %s of account %q (%s)", cluster.Name, *account.AccountName, *account.AccountId),
+				Cluster: map[string]interface{}{
+					"server":                     cluster.Endpoint,
+					"certificate-authority-data": cluster.CertificateAuthorityData,
+				},
+				User: map[string]interface{}{
+					"exec": map[string]interface{}{
+						"apiVersion": "client.authentication.k8s.io/v1beta1",
+						"command":    "aws",
+						"args":       []string{"--region", cluster.Region, "eks", "get-token", "--cluster-name", cluster.Name},
+						"env": []map[string]string{
+							{"name": "AWS_PROFILE", "value": aggregatedProfile(account)},
+						},
+					},
+				},
+			})
+		}
+	}
+	return kubernetesClusters
+}
+
+func updateKubernetesConfigFiles(ssoClient *sso.Client, accessToken string) {
+	clusters := kubernetesClusters(discoverClusters(ssoClient, accessToken))
+
+	fmt.Printf("User has access to %d EKS clusters.\n", len(clusters))
+
+	// A standalone --steampipe-output file only holds the AWS connections
+	output := ""
+	if options.SteampipeOutput == utils.StdoutTarget {
+		output = utils.StdoutTarget
+	}
+
+	err := utils.UpdateKubernetes(kubernetesBlockName(), clusters, output)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
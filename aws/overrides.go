@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides customizes the generated configuration of individual accounts.
+// Accounts are keyed by account ID or a glob matching the account name or normalized account name.
+type Overrides struct {
+	Accounts AccountOverrideList `yaml:"accounts"`
+}
+
+type AccountOverride struct {
+	Match            string                 `yaml:"-"`
+	Region           string                 `yaml:"region"`
+	Output           string                 `yaml:"output"`
+	RoleName         string                 `yaml:"role_name"`
+	Aliases          []string               `yaml:"aliases"`
+	Config           map[string]string      `yaml:"config"`
+	SteampipeRegions []string               `yaml:"steampipe_regions"`
+	Steampipe        SteampipePluginOptions `yaml:"steampipe"`
+	Tags             []string               `yaml:"tags"`
+}
+
+// AccountOverrideList keeps the order of the accounts mapping so later entries win over earlier ones
+type AccountOverrideList []AccountOverride
+
+var overrides Overrides
+
+func (l *AccountOverrideList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: accounts must be a mapping of account ID or name glob to overrides", value.Line)
+	}
+	for i := 0; i < len(value.Content); i += 2 {
+		override := AccountOverride{}
+		if err := value.Content[i+1].Decode(&override); err != nil {
+			return err
+		}
+		override.Match = value.Content[i].Value
+		if _, err := path.Match(override.Match, ""); err != nil {
+			return fmt.Errorf("line %d: invalid account pattern %q: %v", value.Content[i].Line, override.Match, err)
+		}
+		*l = append(*l, override)
+	}
+	return nil
+}
+
+func loadOverrides(overridesFile string) {
+	if overridesFile == "" {
+		return
+	}
+
+	contents, err := ioutil.ReadFile(overridesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := yaml.Unmarshal(contents, &overrides); err != nil {
+		log.Fatalf("failed to parse overrides file %s: %v", overridesFile, err)
+	}
+}
+
+func (o AccountOverride) matches(account AWSAccountInfo) bool {
+	for _, name := range []string{*account.AccountId, *account.AccountName, account.NormalizedAccountName} {
+		if ok, _ := path.Match(o.Match, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOverrides fills in the per-account settings from the command line defaults and the overrides file
+func applyOverrides(account *AWSAccountInfo) {
+	account.Region = options.DefaultRegion
+	account.Output = options.DefaultFormat
+	account.RoleName = options.SSORoleName
+	account.Config = map[string]string{}
+	account.SteampipeRegions = options.Regions.All
+	account.Steampipe = defaultPluginOptions()
+
+	for _, o := range overrides.Accounts {
+		if !o.matches(*account) {
+			continue
+		}
+		if o.Region != "" {
+			account.Region = o.Region
+		}
+		if o.Output != "" {
+			account.Output = o.Output
+		}
+		if o.RoleName != "" {
+			account.RoleName = o.RoleName
+		}
+		if len(o.SteampipeRegions) > 0 {
+			account.SteampipeRegions = o.SteampipeRegions
+		}
+		account.Steampipe.merge(o.Steampipe)
+		account.Aliases = append(account.Aliases, o.Aliases...)
+		account.Tags = append(account.Tags, o.Tags...)
+		for key, value := range o.Config {
+			account.Config[key] = value
+		}
+	}
+
+	account.IDProfile = *account.AccountId
+	account.NameProfile = account.NormalizedAccountName
+	account.Profiles = append([]string{account.NormalizedAccountName, *account.AccountId}, account.Aliases...)
+}
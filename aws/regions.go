@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sso"
+)
+
+// AutoRegions is the --regions value that discovers the enabled regions of each account
+const AutoRegions = "auto"
+
+// Number of accounts whose regions are discovered at the same time
+const regionDiscoveryConcurrency = 8
+
+// Auto reports whether the regions are discovered per account instead of listed
+func (r Regions) Auto() bool {
+	return len(r.All) == 1 && r.All[0] == AutoRegions
+}
+
+// needsRegionDiscovery reports whether --regions or the overrides file set the regions of any account to auto
+func needsRegionDiscovery() bool {
+	for _, account := range accounts {
+		if (Regions{All: account.SteampipeRegions}).Auto() {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverRegions records the enabled regions of every account and uses them as the account's Steampipe
+// regions, unless they are set in the overrides file. Accounts whose regions cannot be listed keep the
+// Steampipe defaults.
+func discoverRegions(ssoClient *sso.Client, accessToken string) {
+	fmt.Printf("Discovering enabled regions of %d accounts... ", len(accounts))
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(accounts))
+	limit := make(chan struct{}, regionDiscoveryConcurrency)
+
+	for i := range accounts {
+		wg.Add(1)
+		go func(account *AWSAccountInfo, err *error) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			account.EnabledRegions, *err = enabledRegions(ssoClient, accessToken, *account)
+		}(&accounts[i], &errs[i])
+	}
+	wg.Wait()
+
+	fmt.Println("done.")
+
+	for i, account := range accounts {
+		if errs[i] != nil {
+			fmt.Printf("Warning: could not list the regions of %s (%s), using the Steampipe defaults: %v\n", *account.AccountName, *account.AccountId, errs[i])
+		}
+		if (Regions{All: account.SteampipeRegions}).Auto() {
+			accounts[i].SteampipeRegions = account.EnabledRegions
+		}
+	}
+}
+
+// enabledRegions lists the regions that are enabled in an account with the account's role credentials
+func enabledRegions(ssoClient *sso.Client, accessToken string, account AWSAccountInfo) ([]string, error) {
+	roleCredentials, err := getRoleCredentials(ssoClient, accessToken, *account.AccountId, account.RoleName)
+	if err != nil {
+		return nil, err
+	}
+
+	ec2Client := ec2.New(ec2.Options{
+		Region: account.Region,
+		Credentials: credentials.NewStaticCredentialsProvider(
+			aws.ToString(roleCredentials.AccessKeyId),
+			aws.ToString(roleCredentials.SecretAccessKey),
+			aws.ToString(roleCredentials.SessionToken),
+		),
+	})
+
+	output, err := ec2Client.DescribeRegions(context.TODO(), &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	var regions []string
+	for _, region := range output.Regions {
+		regions = append(regions, aws.ToString(region.RegionName))
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
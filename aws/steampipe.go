@@ -0,0 +1,269 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+// SteampipePluginOptions are the AWS plugin arguments written to the connections of an account
+type SteampipePluginOptions struct {
+	Plugin                string   `yaml:"plugin"`
+	IgnoreErrorCodes      []string `yaml:"ignore_error_codes"`
+	MaxErrorRetryAttempts int      `yaml:"max_error_retry_attempts"`
+	MinErrorRetryDelay    int      `yaml:"min_error_retry_delay"`
+	DefaultRegion         string   `yaml:"default_region"`
+	EndpointURL           string   `yaml:"endpoint_url"`
+}
+
+// defaultPluginOptions returns the plugin arguments set on the command line
+func defaultPluginOptions() SteampipePluginOptions {
+	return SteampipePluginOptions{
+		Plugin:                options.SteampipePlugin,
+		IgnoreErrorCodes:      options.SteampipeIgnoreErrorCodes.All,
+		MaxErrorRetryAttempts: options.SteampipeMaxErrorRetryAttempts,
+		MinErrorRetryDelay:    options.SteampipeMinErrorRetryDelay,
+		DefaultRegion:         options.SteampipeDefaultRegion,
+		EndpointURL:           options.SteampipeEndpointURL,
+	}
+}
+
+// merge overwrites the arguments that are set in other
+func (o *SteampipePluginOptions) merge(other SteampipePluginOptions) {
+	if other.Plugin != "" {
+		o.Plugin = other.Plugin
+	}
+	if len(other.IgnoreErrorCodes) > 0 {
+		o.IgnoreErrorCodes = other.IgnoreErrorCodes
+	}
+	if other.MaxErrorRetryAttempts != 0 {
+		o.MaxErrorRetryAttempts = other.MaxErrorRetryAttempts
+	}
+	if other.MinErrorRetryDelay != 0 {
+		o.MinErrorRetryDelay = other.MinErrorRetryDelay
+	}
+	if other.DefaultRegion != "" {
+		o.DefaultRegion = other.DefaultRegion
+	}
+	if other.EndpointURL != "" {
+		o.EndpointURL = other.EndpointURL
+	}
+}
+
+// attributes returns the optional plugin arguments that are set, in the order they are written
+func (o SteampipePluginOptions) attributes() []utils.HCLAttribute {
+	var attributes []utils.HCLAttribute
+	if o.DefaultRegion != "" {
+		attributes = append(attributes, utils.StringAttribute("default_region", o.DefaultRegion))
+	}
+	if o.EndpointURL != "" {
+		attributes = append(attributes, utils.StringAttribute("endpoint_url", o.EndpointURL))
+	}
+	if codes := nonEmpty(o.IgnoreErrorCodes); len(codes) > 0 {
+		attributes = append(attributes, utils.ListAttribute("ignore_error_codes", codes))
+	}
+	if o.MaxErrorRetryAttempts != 0 {
+		attributes = append(attributes, utils.IntAttribute("max_error_retry_attempts", o.MaxErrorRetryAttempts))
+	}
+	if o.MinErrorRetryDelay != 0 {
+		attributes = append(attributes, utils.IntAttribute("min_error_retry_delay", o.MinErrorRetryDelay))
+	}
+	return attributes
+}
+
+// Steampipe connection naming schemes
+const (
+	ConnectionsByID   = "id"
+	ConnectionsByName = "name"
+	ConnectionsBoth   = "both"
+)
+
+// perRegionAggregator prefixes the aggregators of the per-region connections, such as aws_all_us_east_1
+const perRegionAggregator = "aws_all_"
+
+// aggregatedConnection returns the connection of an account that the aws_all aggregator includes,
+// preferring the ID-based connection when both are generated
+func aggregatedConnection(account AWSAccountInfo) string {
+	if options.SteampipeConnections == ConnectionsByName {
+		return "aws_" + account.NormalizedAccountName
+	}
+	return "aws_" + *account.AccountId
+}
+
+// aggregatedProfile returns the profile used by the connection returned by aggregatedConnection
+func aggregatedProfile(account AWSAccountInfo) string {
+	if options.SteampipeConnections == ConnectionsByName {
+		return account.NameProfile
+	}
+	return account.IDProfile
+}
+
+// regionConnection names the connection to one region of an account, such as aws_123456789012_us_east_1
+func regionConnection(account AWSAccountInfo, region string) string {
+	return aggregatedConnection(account) + "_" + utils.SnakeCase(region)
+}
+
+// steampipeConnections builds the aws_all aggregator and the connections for each account
+func steampipeConnections() []utils.HCLBlock {
+	var connectionNames []string
+	for _, account := range accounts {
+		connectionNames = append(connectionNames, aggregatedConnection(account))
+	}
+
+	aggregator := utils.HCLBlock{
+		Type:   "connection",
+		Labels: []string{"aws_all"},
+		Attributes: []utils.HCLAttribute{
+			utils.StringAttribute("plugin", options.SteampipePlugin),
+			utils.StringAttribute("type", "aggregator"),
+			utils.ListAttribute("connections", connectionNames),
+		},
+	}
+	if regions := steampipeRegions(); len(regions) > 0 {
+		aggregator.Attributes = append(aggregator.Attributes, utils.ListAttribute("regions", regions))
+	}
+
+	connections := []utils.HCLBlock{aggregator}
+	if options.SteampipePerRegion {
+		connections = append(connections, regionAggregators()...)
+	}
+
+	for _, account := range accounts {
+		var accountConnections []utils.HCLBlock
+		if options.SteampipeConnections != ConnectionsByName {
+			accountConnections = append(accountConnections, accountConnection(account, "aws_"+*account.AccountId, account.IDProfile, account.SteampipeRegions))
+		}
+		if options.SteampipeConnections != ConnectionsByID {
+			accountConnections = append(accountConnections, accountConnection(account, "aws_"+account.NormalizedAccountName, account.NameProfile, account.SteampipeRegions))
+		}
+
+		accountConnections[0].Comments = []string{
+			fmt.Sprintf("Account Name: %s", *account.AccountName),
+			fmt.Sprintf("Account Email: %s", *account.EmailAddress),
+		}
+		connections = append(connections, accountConnections...)
+
+		if options.SteampipePerRegion {
+			for _, region := range exactRegions(account.SteampipeRegions) {
+				connections = append(connections, accountConnection(account, regionConnection(account, region), aggregatedProfile(account), []string{region}))
+			}
+		}
+	}
+
+	return utils.ShardAggregator(connections, "aws_all", utils.Settings.AggregatorShardSize)
+}
+
+// regionAggregators builds an aggregator for each region of the per-region connections, in the order
+// the regions are first used
+func regionAggregators() []utils.HCLBlock {
+	var regions []string
+	regionConnections := map[string][]string{}
+	wildcards := map[string]bool{}
+	for _, account := range accounts {
+		for _, region := range nonEmpty(account.SteampipeRegions) {
+			if strings.Contains(region, "*") {
+				if !wildcards[region] {
+					wildcards[region] = true
+					fmt.Printf("Warning: --steampipe-per-region cannot name a connection after the region wildcard %q, no per-region connections were generated for it\n", region)
+				}
+				continue
+			}
+			if _, ok := regionConnections[region]; !ok {
+				regions = append(regions, region)
+			}
+			regionConnections[region] = append(regionConnections[region], regionConnection(account, region))
+		}
+	}
+
+	if len(regions) == 0 {
+		fmt.Println("Warning: --steampipe-per-region needs --regions or steampipe_regions in the overrides file, no per-region connections were generated")
+	}
+
+	var aggregators []utils.HCLBlock
+	for _, region := range regions {
+		aggregators = append(aggregators, utils.HCLBlock{
+			Type:   "connection",
+			Labels: []string{perRegionAggregator + utils.SnakeCase(region)},
+			Attributes: []utils.HCLAttribute{
+				utils.StringAttribute("plugin", options.SteampipePlugin),
+				utils.StringAttribute("type", "aggregator"),
+				utils.ListAttribute("connections", regionConnections[region]),
+			},
+		})
+	}
+	return aggregators
+}
+
+// checkConnectionCount stops when --steampipe-per-region would generate more connections than allowed
+func checkConnectionCount(connections []utils.HCLBlock) error {
+	if !options.SteampipePerRegion || options.SteampipeMaxConnections <= 0 {
+		return nil
+	}
+	if len(connections) > options.SteampipeMaxConnections {
+		return fmt.Errorf("--steampipe-per-region would generate %d Steampipe connections, more than --steampipe-max-connections=%d. Limit the accounts or regions, or raise --steampipe-max-connections", len(connections), options.SteampipeMaxConnections)
+	}
+	return nil
+}
+
+// accountConnection builds a connection to regions of an account through one of its profiles
+func accountConnection(account AWSAccountInfo, name string, profile string, regions []string) utils.HCLBlock {
+	connection := utils.HCLBlock{
+		Type:   "connection",
+		Labels: []string{name},
+		Attributes: []utils.HCLAttribute{
+			utils.StringAttribute("plugin", account.Steampipe.Plugin),
+			utils.StringAttribute("profile", profile),
+		},
+		Source: fmt.Sprintf("account %q (%s)", *account.AccountName, *account.AccountId),
+	}
+	if regions := nonEmpty(regions); len(regions) > 0 {
+		connection.Attributes = append(connection.Attributes, utils.ListAttribute("regions", regions))
+	}
+	connection.Attributes = append(connection.Attributes, account.Steampipe.attributes()...)
+	return connection
+}
+
+// steampipeRegions returns the --regions list, or every region of the accounts with --regions=auto
+func steampipeRegions() []string {
+	if !options.Regions.Auto() {
+		return nonEmpty(options.Regions.All)
+	}
+
+	var regions []string
+	seen := map[string]bool{}
+	for _, account := range accounts {
+		for _, region := range nonEmpty(account.SteampipeRegions) {
+			if !seen[region] {
+				seen[region] = true
+				regions = append(regions, region)
+			}
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// exactRegions drops empty regions and region wildcards such as us-*, which only the regions of a
+// connection can hold
+func exactRegions(regions []string) []string {
+	var result []string
+	for _, region := range nonEmpty(regions) {
+		if !strings.Contains(region, "*") {
+			result = append(result, region)
+		}
+	}
+	return result
+}
+
+// nonEmpty drops empty strings, such as the single empty region parsed from --regions=""
+func nonEmpty(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+// parsedConnections reads the connections of a Steampipe config back and renders them with the HCL
+// writer, so configs that only differ in formatting and comments compare equal
+func parsedConnections(t *testing.T, src string) string {
+	connections, err := utils.ParseConnections("aws.spc", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range connections {
+		connections[i].Source = ""
+	}
+	return utils.RenderHCL(connections)
+}
+
+func TestSteampipeTemplateMatchesWriter(t *testing.T) {
+	previousOptions, previousAccounts := options, accounts
+	t.Cleanup(func() { options, accounts = previousOptions, previousAccounts })
+
+	options = &Options{
+		SteampipePlugin:           "aws@^0.80",
+		SteampipeConnections:      "both",
+		SteampipePerRegion:        true,
+		Regions:                   Regions{All: []string{"us-east-1", "us-west-2"}},
+		SteampipeIgnoreErrorCodes: &ErrorCodes{All: []string{"AccessDenied"}},
+		SteampipeDefaultRegion:    "us-east-1",
+	}
+	accounts = nil
+	for _, info := range []ssotypes.AccountInfo{
+		{AccountId: aws.String("111111111111"), AccountName: aws.String(`Dept "Prod" ${account}`), EmailAddress: aws.String("prod@example.edu")},
+		{AccountId: aws.String("222222222222"), AccountName: aws.String("Dept Dev %{x}"), EmailAddress: aws.String("dev@example.edu")},
+	} {
+		account := AWSAccountInfo{AccountInfo: info, NormalizedAccountName: utils.SnakeCase(*info.AccountName)}
+		applyOverrides(&account)
+		accounts = append(accounts, account)
+	}
+
+	connections := steampipeConnections()
+
+	tmpl := template.Must(template.New("aws/steampipe.gospc").Funcs(utils.TemplateFuncs).Parse(steampipeTemplateString))
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, SteampipeTemplateData{Connections: connections, Marker: utils.Marker}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := parsedConnections(t, buffer.String())
+	want := parsedConnections(t, "\n"+utils.RenderHCL(connections))
+	if got != want {
+		t.Errorf("the built-in template and the HCL writer differ:\ntemplate:\n%s\nwriter:\n%s", got, want)
+	}
+}
+
+func TestPerRegionSkipsWildcards(t *testing.T) {
+	previousOptions, previousAccounts := options, accounts
+	t.Cleanup(func() { options, accounts = previousOptions, previousAccounts })
+
+	options = &Options{
+		SteampipePlugin:           "aws",
+		SteampipeConnections:      ConnectionsByID,
+		SteampipePerRegion:        true,
+		Regions:                   Regions{All: []string{"us-east-1", "us-*", "*"}},
+		SteampipeIgnoreErrorCodes: &ErrorCodes{},
+	}
+	account := AWSAccountInfo{AccountInfo: ssotypes.AccountInfo{AccountId: aws.String("111111111111"), AccountName: aws.String("Dept Prod"), EmailAddress: aws.String("prod@example.edu")}, NormalizedAccountName: "dept_prod"}
+	applyOverrides(&account)
+	accounts = []AWSAccountInfo{account}
+
+	var names []string
+	for _, connection := range steampipeConnections() {
+		names = append(names, connection.Labels[0])
+	}
+	want := []string{"aws_all", "aws_all_us_east_1", "aws_111111111111", "aws_111111111111_us_east_1"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("got connections %v, want %v", names, want)
+	}
+}
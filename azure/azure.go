@@ -6,9 +6,7 @@ import (
 	_ "embed"
 	"fmt"
 	"log"
-	"os"
 	"path/filepath"
-	"strings"
 	"text/template"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -38,12 +36,33 @@ type Subscription struct {
 type SteampipeTemplateData struct {
 	AggregationString string
 	Subscriptions     []Subscription
+	Connections       []utils.HCLBlock
 	TenantID          string
 	Marker            string
 }
 
+func steampipeConfigFilePath() string {
+	return filepath.Join(utils.SteampipeConfigDir(), "azure.spc")
+}
+
+// Targets returns every file the azure command writes managed blocks to
+func Targets() []string {
+	return []string{steampipeConfigFilePath(), azureadConfigFilePath(), utils.WorkspacesFilePath(), flowpipeConfigFilePath(), utils.KubernetesConfigFilePath()}
+}
+
+// Templates returns the built-in templates keyed by the name used to override them
+func Templates() map[string]string {
+	return map[string]string{
+		"azure/steampipe.gospc": steampipeTemplateString,
+	}
+}
+
 func Init() {
-	steampipeTemplate = template.Must(template.New("steampipeTemplate").Parse(steampipeTemplateString))
+	if options.SteampipeOutput == utils.StdoutTarget {
+		utils.ProgressToStderr()
+	}
+
+	steampipeTemplate = utils.LoadTemplate("azure/steampipe.gospc", steampipeTemplateString)
 
 	err := authenticate()
 	if err != nil {
@@ -53,9 +72,29 @@ func Init() {
 	fmt.Println("Updating Steampipe Azure Plugin config file with connections.")
 	updateSteampipeAzureConfigFile()
 
+	if options.AzureAD {
+		fmt.Println("Updating Steampipe Azure AD Plugin config file with connections.")
+		updateSteampipeAzureadConfigFile()
+	}
+
+	if utils.Settings.Flowpipe {
+		fmt.Println("Updating Flowpipe Azure credentials file.")
+		updateFlowpipeConfigFile()
+	}
+
+	if utils.Settings.Kubernetes {
+		fmt.Println("Updating kubeconfig and Steampipe Kubernetes Plugin config files with AKS clusters.")
+		updateKubernetesConfigFiles()
+	}
+
 	fmt.Println("Done.")
 }
 
+// blockName names the managed blocks of this tenant so several tenants can share a file
+func blockName() string {
+	return utils.BlockName("azure", options.TenantID)
+}
+
 func authenticate() error {
 	var err error
 
@@ -144,7 +183,6 @@ func enumSubscriptionsByMgmtGroup() ([]Subscription, error) {
 }
 
 func updateSteampipeAzureConfigFile() {
-	var spcTemplateBuffer bytes.Buffer
 	var err error = nil
 
 	steampipeTemplateData.TenantID = options.TenantID
@@ -161,22 +199,48 @@ func updateSteampipeAzureConfigFile() {
 		log.Fatalf("failed to enumerate subscriptions: %v", err)
 	}
 
+	var connectionNames []string
 	for _, subscription := range steampipeTemplateData.Subscriptions {
-		steampipeTemplateData.AggregationString = steampipeTemplateData.AggregationString + "\"azure_" + subscription.NormalizedName + "\", "
+		connectionNames = append(connectionNames, "azure_"+subscription.NormalizedName)
+	}
+	steampipeTemplateData.AggregationString = utils.HCLJoin(connectionNames)
+	steampipeTemplateData.Connections = steampipeConnections()
+
+	var section string
+	if utils.HasTemplateOverride("azure/steampipe.gospc") {
+		var spcTemplateBuffer bytes.Buffer
+		err = steampipeTemplate.Execute(&spcTemplateBuffer, steampipeTemplateData)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		section = spcTemplateBuffer.String()
+	} else {
+		section = "\n" + utils.RenderHCL(steampipeTemplateData.Connections)
 	}
 
-	steampipeTemplateData.AggregationString = strings.Trim(steampipeTemplateData.AggregationString, ", ")
+	spcFilePath := steampipeConfigFilePath()
 
-	err = steampipeTemplate.Execute(&spcTemplateBuffer, steampipeTemplateData)
+	err = utils.ValidateSteampipeSection(spcFilePath, section, steampipeTemplateData.Connections)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	spcFilePath := filepath.Join(homeDir, ".steampipe/config/azure.spc")
+	if options.SteampipeOutput == "" {
+		err = utils.CheckSteampipeConflicts(spcFilePath, blockName(), section)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
 
-	err = utils.CreateOrReplaceInFile(spcFilePath, spcTemplateBuffer.String())
+	err = utils.WriteOutput(options.SteampipeOutput, spcFilePath, blockName(), section)
 	if err != nil {
 		log.Fatalln(err)
 	}
+
+	if options.SteampipeOutput == "" {
+		err = utils.UpdateSteampipeSettings(spcFilePath, blockName(), steampipeTemplateData.Connections)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
 }
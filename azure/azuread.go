@@ -0,0 +1,131 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/subscription/armsubscription"
+	"github.com/tamu-edu/aiphelper/utils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func azureadConfigFilePath() string {
+	return filepath.Join(utils.SteampipeConfigDir(), "azuread.spc")
+}
+
+// azureadBlockName names the managed blocks of the Azure AD connections. It starts like blockName so
+// aiphelper clean --provider azure removes them too.
+func azureadBlockName() string {
+	return utils.BlockName("azure", options.TenantID+":azuread")
+}
+
+// enumTenantsForCurrentUser lists the tenants the credentials have access to
+func enumTenantsForCurrentUser() ([]string, error) {
+	client, err := armsubscription.NewTenantsClient(cred, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var tenants []string
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		nextResult, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range nextResult.Value {
+			if v.TenantID != nil {
+				tenants = append(tenants, *v.TenantID)
+			}
+		}
+	}
+	return tenants, nil
+}
+
+// azureadConnections builds the azuread_all aggregator and a connection for each tenant, authenticated
+// like the Azure connections
+func azureadConnections(tenants []string) []utils.HCLBlock {
+	var connectionNames []string
+	for _, tenant := range tenants {
+		connectionNames = append(connectionNames, "azuread_"+utils.SnakeCase(tenant))
+	}
+
+	connections := []utils.HCLBlock{{
+		Type:   "connection",
+		Labels: []string{"azuread_all"},
+		Attributes: []utils.HCLAttribute{
+			utils.StringAttribute("plugin", "azuread"),
+			utils.StringAttribute("type", "aggregator"),
+			utils.ListAttribute("connections", connectionNames),
+		},
+	}}
+
+	for i, tenant := range tenants {
+		connection := utils.HCLBlock{
+			Type:     "connection",
+			Labels:   []string{connectionNames[i]},
+			Comments: []string{fmt.Sprintf("Tenant ID: %s", tenant)},
+			Attributes: []utils.HCLAttribute{
+				utils.StringAttribute("plugin", "azuread"),
+				utils.StringAttribute("tenant_id", tenant),
+			},
+			Source: fmt.Sprintf("tenant %s", tenant),
+		}
+		// The other authentication methods are picked up by the plugin from the environment or the Azure CLI
+		if options.AuthenticationMethod == "managed-identity" {
+			connection.Attributes = append(connection.Attributes, utils.HCLAttribute{Name: "enable_msi", Value: cty.True})
+		}
+		connections = append(connections, connection)
+	}
+
+	return connections
+}
+
+func updateSteampipeAzureadConfigFile() {
+	tenants, err := enumTenantsForCurrentUser()
+	if err != nil {
+		log.Fatalf("failed to enumerate tenants: %v", err)
+	}
+	if len(tenants) == 0 {
+		tenants = []string{options.TenantID}
+	}
+
+	fmt.Printf("User has access to %d Azure AD tenants.\n", len(tenants))
+
+	connections := azureadConnections(tenants)
+	section := "\n" + utils.RenderHCL(connections)
+
+	spcFilePath := azureadConfigFilePath()
+
+	err = utils.ValidateSteampipeSection(spcFilePath, section, connections)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// A standalone --steampipe-output file only holds the Azure connections
+	output := ""
+	if options.SteampipeOutput == utils.StdoutTarget {
+		output = utils.StdoutTarget
+	}
+
+	if output == "" {
+		err = utils.CheckSteampipeConflicts(spcFilePath, azureadBlockName(), section)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	err = utils.WriteOutput(output, spcFilePath, azureadBlockName(), section)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if output == "" {
+		err = utils.UpdateSteampipeSettings(spcFilePath, azureadBlockName(), connections)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
@@ -7,6 +7,8 @@ type Options struct {
 	EnumManagementGroup  bool   `long:"enum-mgmt-group" short:"g" description:"Use an Azure Management Group to enumerate descendants for a list of Subscriptions"`
 	RootManagementGroup  string `long:"root-group" default:"tamu" description:"management group IDs to begin search for subscriptions"`
 	AuthenticationMethod string `long:"auth-method" default:"default" description:"Authentication method to use. Options: [environment, cli, managed-identity, device-code, default]"`
+	SteampipeOutput      string `long:"steampipe-output" description:"Write the Steampipe connections block to - (stdout) or to a standalone file instead of azure.spc"`
+	AzureAD              bool   `long:"azuread" description:"Also write Steampipe Azure AD connections for every tenant to azuread.spc"`
 	// ExcludeManagementGroups []string `long:"exclude-groups" short:"e" default:"sandbox" description:"comma-separated list of one or more nested management group IDs to exclude"`
 }
 
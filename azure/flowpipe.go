@@ -0,0 +1,52 @@
+package azure
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+func flowpipeConfigFilePath() string {
+	return filepath.Join(utils.FlowpipeConfigDir(), "azure.fpc")
+}
+
+// flowpipeCredentials builds a Flowpipe credential for the tenant, such as azure_00000000_0000_0000_0000_000000000000.
+// Flowpipe credentials are scoped to a tenant rather than a subscription, so one covers every subscription.
+func flowpipeCredentials() []utils.HCLBlock {
+	if len(steampipeTemplateData.Subscriptions) == 0 {
+		return nil
+	}
+
+	comments := []string{fmt.Sprintf("Tenant ID: %s", steampipeTemplateData.TenantID)}
+	for _, subscription := range steampipeTemplateData.Subscriptions {
+		comments = append(comments, fmt.Sprintf("Subscription Name: %s (%s)", subscription.Name, subscription.ID))
+	}
+	return []utils.HCLBlock{{
+		Type:     "credential",
+		Labels:   []string{"azure", "azure_" + utils.SnakeCase(steampipeTemplateData.TenantID)},
+		Comments: comments,
+		Attributes: []utils.HCLAttribute{
+			utils.StringAttribute("tenant_id", steampipeTemplateData.TenantID),
+		},
+		Source: fmt.Sprintf("tenant %s", steampipeTemplateData.TenantID),
+	}}
+}
+
+func updateFlowpipeConfigFile() {
+	credentials := flowpipeCredentials()
+	section := "\n" + utils.RenderHCL(credentials)
+
+	fpcFilePath := flowpipeConfigFilePath()
+
+	err := utils.ValidateFlowpipeSection(fpcFilePath, section, credentials)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = utils.CreateOrReplaceInFile(fpcFilePath, blockName(), section)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
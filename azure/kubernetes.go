@@ -0,0 +1,136 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice"
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+// aksCluster is an AKS cluster of a subscription with the kubeconfig of the current user
+type aksCluster struct {
+	Name          string
+	ResourceGroup string
+	ID            string
+	Kubeconfig    []byte
+}
+
+// kubernetesBlockName names the managed blocks of the AKS clusters. It starts like blockName so
+// aiphelper clean --provider azure removes them too.
+func kubernetesBlockName() string {
+	return blockName() + ":aks"
+}
+
+// enumClustersForSubscription lists the AKS clusters of a subscription with the credentials of the current user.
+// Clusters that use Azure AD get an exec kubeconfig, which needs kubelogin. Clusters whose credentials cannot
+// be listed are skipped with a warning and keep a nil Kubeconfig, so their names still count as duplicates.
+func enumClustersForSubscription(subscription Subscription) ([]aksCluster, error) {
+	ctx := context.Background()
+	client, err := armcontainerservice.NewManagedClustersClient(subscription.ID, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []aksCluster
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		nextResult, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range nextResult.Value {
+			id, err := arm.ParseResourceID(*v.ID)
+			if err != nil {
+				return nil, err
+			}
+			clusters = append(clusters, aksCluster{Name: *v.Name, ResourceGroup: id.ResourceGroupName, ID: *v.ID})
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].ID < clusters[j].ID })
+
+	format := armcontainerservice.FormatExec
+	for i, cluster := range clusters {
+		credentials, err := client.ListClusterUserCredentials(ctx, cluster.ResourceGroup, cluster.Name,
+			&armcontainerservice.ManagedClustersClientListClusterUserCredentialsOptions{Format: &format})
+		if err != nil {
+			fmt.Printf("Warning: skipping AKS cluster %s: %v\n", cluster.ID, err)
+			continue
+		}
+		if len(credentials.Kubeconfigs) == 0 {
+			fmt.Printf("Warning: skipping AKS cluster %s: no kubeconfig was returned\n", cluster.ID)
+			continue
+		}
+		clusters[i].Kubeconfig = credentials.Kubeconfigs[0].Value
+	}
+	return clusters, nil
+}
+
+// kubernetesClusters builds a kubeconfig context and Steampipe connection for each AKS cluster, such as
+// kubernetes_azure_my_subscription_my_cluster. Clusters of a subscription with the same name in several
+// resource groups get the resource group appended, and names longer than Steampipe allows are shortened.
+// Subscriptions whose clusters cannot be listed, and clusters without credentials, are skipped with a warning.
+func kubernetesClusters() []utils.KubernetesCluster {
+	var kubernetesClusters []utils.KubernetesCluster
+	for _, subscription := range steampipeTemplateData.Subscriptions {
+		clusters, err := enumClustersForSubscription(subscription)
+		if err != nil {
+			fmt.Printf("Warning: could not list the AKS clusters of %s (%s): %v\n", subscription.Name, subscription.ID, err)
+			continue
+		}
+
+		names := map[string]int{}
+		for _, cluster := range clusters {
+			names[cluster.Name]++
+		}
+
+		for _, cluster := range clusters {
+			if cluster.Kubeconfig == nil {
+				continue
+			}
+			connection := "kubernetes_azure_" + subscription.NormalizedName + "_" + utils.SnakeCase(cluster.Name)
+			if names[cluster.Name] > 1 {
+				connection += "_" + utils.SnakeCase(cluster.ResourceGroup)
+			}
+			connection = utils.ShortenConnectionName(connection)
+
+			kubeconfigCluster, kubeconfigUser, err := utils.ParseKubeconfig(cluster.Kubeconfig)
+			if err != nil {
+				fmt.Printf("Warning: skipping AKS cluster %s: %v\n", cluster.ID, err)
+				continue
+			}
+
+			kubernetesClusters = append(kubernetesClusters, utils.KubernetesCluster{
+				Connection: connection,
+				Comments: []string{
+					fmt.Sprintf("Subscription Name: %s", subscription.Name),
+					fmt.Sprintf("Cluster: %s", cluster.ID),
+				},
+				Source:  fmt.Sprintf("AKS cluster %s of subscription %q (%s)", cluster.Name, subscription.Name, subscription.ID),
+				Cluster: kubeconfigCluster,
+				User:    kubeconfigUser,
+			})
+		}
+	}
+	return kubernetesClusters
+}
+
+func updateKubernetesConfigFiles() {
+	clusters := kubernetesClusters()
+
+	fmt.Printf("User has access to %d AKS clusters.\n", len(clusters))
+
+	// A standalone --steampipe-output file only holds the Azure connections
+	output := ""
+	if options.SteampipeOutput == utils.StdoutTarget {
+		output = utils.StdoutTarget
+	}
+
+	err := utils.UpdateKubernetes(kubernetesBlockName(), clusters, output)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,44 @@
+package azure
+
+import (
+	"fmt"
+
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+// steampipeConnections builds the azure_all aggregator and the connection for each subscription
+func steampipeConnections() []utils.HCLBlock {
+	var connectionNames []string
+	for _, subscription := range steampipeTemplateData.Subscriptions {
+		connectionNames = append(connectionNames, "azure_"+subscription.NormalizedName)
+	}
+
+	connections := []utils.HCLBlock{{
+		Type:   "connection",
+		Labels: []string{"azure_all"},
+		Attributes: []utils.HCLAttribute{
+			utils.StringAttribute("plugin", "azure"),
+			utils.StringAttribute("type", "aggregator"),
+			utils.ListAttribute("connections", connectionNames),
+		},
+	}}
+
+	for _, subscription := range steampipeTemplateData.Subscriptions {
+		connections = append(connections, utils.HCLBlock{
+			Type:   "connection",
+			Labels: []string{"azure_" + subscription.NormalizedName},
+			Comments: []string{
+				fmt.Sprintf("Subscription Name: %s", subscription.Name),
+				fmt.Sprintf("ID: %s", subscription.ID),
+			},
+			Attributes: []utils.HCLAttribute{
+				utils.StringAttribute("plugin", "azure"),
+				utils.StringAttribute("tenant_id", steampipeTemplateData.TenantID),
+				utils.StringAttribute("subscription_id", subscription.ID),
+			},
+			Source: fmt.Sprintf("subscription %q (%s)", subscription.Name, subscription.ID),
+		})
+	}
+
+	return utils.ShardAggregator(connections, "azure_all", utils.Settings.AggregatorShardSize)
+}
@@ -0,0 +1,74 @@
+package azure
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+// parsedConnections reads the connections of a Steampipe config back and renders them with the HCL
+// writer, so configs that only differ in formatting and comments compare equal
+func parsedConnections(t *testing.T, src string) string {
+	connections, err := utils.ParseConnections("azure.spc", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range connections {
+		connections[i].Source = ""
+	}
+	return utils.RenderHCL(connections)
+}
+
+func TestSteampipeTemplateMatchesWriter(t *testing.T) {
+	previous := steampipeTemplateData
+	t.Cleanup(func() { steampipeTemplateData = previous })
+
+	steampipeTemplateData = SteampipeTemplateData{
+		TenantID: "00000000-0000-0000-0000-000000000000",
+		Subscriptions: []Subscription{
+			{Name: `Dept "Prod" ${subscription}`, ID: "11111111-1111-1111-1111-111111111111", NormalizedName: "dept_prod_subscription"},
+			{Name: "Dept Dev %{x}", ID: "22222222-2222-2222-2222-222222222222", NormalizedName: "dept_dev_x"},
+		},
+		Marker: utils.Marker,
+	}
+	steampipeTemplateData.Connections = steampipeConnections()
+
+	tmpl := template.Must(template.New("azure/steampipe.gospc").Funcs(utils.TemplateFuncs).Parse(steampipeTemplateString))
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, steampipeTemplateData); err != nil {
+		t.Fatal(err)
+	}
+
+	got := parsedConnections(t, buffer.String())
+	want := parsedConnections(t, "\n"+utils.RenderHCL(steampipeTemplateData.Connections))
+	if got != want {
+		t.Errorf("the built-in template and the HCL writer differ:\ntemplate:\n%s\nwriter:\n%s", got, want)
+	}
+}
+
+func TestFlowpipeCredentialPerTenant(t *testing.T) {
+	previous := steampipeTemplateData
+	t.Cleanup(func() { steampipeTemplateData = previous })
+
+	steampipeTemplateData = SteampipeTemplateData{
+		TenantID: "00000000-0000-0000-0000-000000000000",
+		Subscriptions: []Subscription{
+			{Name: "Dept Prod", ID: "11111111-1111-1111-1111-111111111111", NormalizedName: "dept_prod"},
+			{Name: "Dept Dev", ID: "22222222-2222-2222-2222-222222222222", NormalizedName: "dept_dev"},
+		},
+	}
+	credentials := flowpipeCredentials()
+	if len(credentials) != 1 {
+		t.Fatalf("got %d credentials for one tenant, want 1", len(credentials))
+	}
+	if err := utils.ValidateFlowpipeSection("azure.fpc", "\n"+utils.RenderHCL(credentials), credentials); err != nil {
+		t.Error(err)
+	}
+
+	steampipeTemplateData.Subscriptions = nil
+	if credentials := flowpipeCredentials(); len(credentials) != 0 {
+		t.Errorf("got %d credentials for a tenant without subscriptions, want none", len(credentials))
+	}
+}
@@ -0,0 +1,88 @@
+package backups
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+func Init() {
+	switch command.Active.Name {
+	case "list":
+		list()
+	}
+}
+
+func list() {
+	backups, err := utils.ListBackups()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(backups) == 0 {
+		fmt.Printf("No backups found in %s\n", utils.BackupDir())
+		return
+	}
+
+	for _, backup := range backups {
+		fmt.Println(backup.Timestamp)
+		for _, path := range sortedPaths(backup) {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+}
+
+func Restore() {
+	backups, err := utils.ListBackups()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if len(backups) == 0 {
+		log.Fatalf("No backups found in %s", utils.BackupDir())
+	}
+
+	backup := backups[len(backups)-1]
+	if restoreOptions.At != "" {
+		found := false
+		for _, b := range backups {
+			if b.Timestamp == restoreOptions.At {
+				backup, found = b, true
+			}
+		}
+		if !found {
+			log.Fatalf("No backup with timestamp %s, see aiphelper backups list", restoreOptions.At)
+		}
+	}
+
+	paths := sortedPaths(backup)
+	if len(restoreOptions.Files) > 0 {
+		paths = []string{}
+		for _, file := range restoreOptions.Files {
+			path, err := filepath.Abs(file)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	for _, path := range paths {
+		fmt.Printf("Restoring %s from backup %s\n", path, backup.Timestamp)
+		if err := utils.RestoreFile(backup, path); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	fmt.Println("Done.")
+}
+
+func sortedPaths(backup utils.Backup) []string {
+	paths := make([]string, 0, len(backup.Files))
+	for path := range backup.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,21 @@
+package backups
+
+import "github.com/jessevdk/go-flags"
+
+var (
+	command        *flags.Command
+	restoreOptions *RestoreOptions
+)
+
+type RestoreOptions struct {
+	At    string   `long:"at" description:"Timestamp of the backup to restore, as shown by aiphelper backups list (default: the latest backup)"`
+	Files []string `long:"file" description:"File to restore; may be repeated (default: every file in the backup)"`
+}
+
+func AddCommand(p *flags.Parser) {
+	command, _ = p.AddCommand("backups", "Manage backups", "Manage the backups taken before aiphelper changes a file", &struct{}{})
+	command.AddCommand("list", "List backups", "List the backups in ~/.aiphelper/backups and the files they contain", &struct{}{})
+
+	restoreOptions = &RestoreOptions{}
+	p.AddCommand("restore", "Restore files from a backup", "Restore files from a backup taken before aiphelper changed them", restoreOptions)
+}
@@ -0,0 +1,106 @@
+package clean
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/tamu-edu/aiphelper/aws"
+	"github.com/tamu-edu/aiphelper/azure"
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+func Init() {
+	targets := map[string][]string{
+		"aws":   aws.Targets(),
+		"azure": azure.Targets(),
+	}
+
+	for _, provider := range []string{"aws", "azure"} {
+		if options.Provider != "all" && options.Provider != provider {
+			continue
+		}
+		for _, path := range targets[provider] {
+			cleanFile(provider, path)
+		}
+		// Kubeconfig files are written whole rather than as managed blocks
+		for _, path := range utils.KubeconfigFilePaths(provider) {
+			if err := utils.RemoveFile(path); err != nil {
+				log.Fatalln(err)
+			}
+		}
+	}
+
+	// The all-clouds workspace, the kubernetes_all aggregator and the performance options are shared by
+	// every provider, so they are only removed with the last of them
+	if options.Provider == "all" {
+		cleanFile("steampipe", utils.WorkspacesFilePath())
+		cleanFile("steampipe", utils.KubernetesConfigFilePath())
+		cleanFile("steampipe", utils.DefaultConfigFilePath())
+	} else {
+		aggregator, err := utils.UpdateKubernetesAggregator(nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		profile, err := utils.CurrentPerformanceProfile("", "", nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := utils.UpdateKubernetesWorkspace(aggregator, profile); err != nil {
+			log.Fatalln(err)
+		}
+		if err := utils.UpdateAllCloudsWorkspace(nil, profile); err != nil {
+			log.Fatalln(err)
+		}
+		if profile != nil && profile.Connections == 0 {
+			profile = nil
+		}
+		if profile != nil {
+			fmt.Printf("Updating Steampipe %s profile for %d connections.\n", profile.Name, profile.Connections)
+		}
+		if err := utils.UpdatePerformanceProfile(profile); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if options.PurgeCache && options.Provider != "azure" {
+		if err := aws.PurgeTokenCache(options.SSOStartURL); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	fmt.Println("Done.")
+}
+
+// cleanFile removes the provider's blocks from a file. Unnamed blocks from older versions belong to the provider whose file they are in.
+func cleanFile(provider string, path string) {
+	fileContents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var names []string
+	for _, block := range utils.FindBlocks(strings.Split(string(fileContents), "\n")) {
+		if block.Name != "" && !strings.HasPrefix(block.Name, provider+":") {
+			continue
+		}
+		name := block.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		fmt.Printf("Removing block %s (%d lines) from %s\n", name, block.End-block.Begin+1, path)
+		names = append(names, block.Name)
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	if err := utils.RemoveBlocksFromFile(path, names); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,16 @@
+package clean
+
+import "github.com/jessevdk/go-flags"
+
+var options *Options
+
+type Options struct {
+	Provider    string `long:"provider" default:"all" choice:"aws" choice:"azure" choice:"all" description:"Provider whose managed configuration is removed"`
+	PurgeCache  bool   `long:"purge-cache" description:"Also remove the AWS SSO access token cached by aiphelper for --sso-start-url"`
+	SSOStartURL string `long:"sso-start-url" default:"https://aggie-innovation-platform.awsapps.com/start" description:"AWS SSO Start URL whose cached access token --purge-cache removes"`
+}
+
+func AddCommand(p *flags.Parser) {
+	options = &Options{}
+	p.AddCommand("clean", "Remove managed configuration", "Remove every block aiphelper manages from the files it writes", options)
+}
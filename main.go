@@ -7,6 +7,11 @@ import (
 	"github.com/jessevdk/go-flags"
 	"github.com/tamu-edu/aiphelper/aws"
 	"github.com/tamu-edu/aiphelper/azure"
+	"github.com/tamu-edu/aiphelper/backups"
+	"github.com/tamu-edu/aiphelper/clean"
+	"github.com/tamu-edu/aiphelper/templates"
+	"github.com/tamu-edu/aiphelper/utils"
+	"github.com/tamu-edu/aiphelper/validate"
 )
 
 // https://lightstep.com/blog/getting-real-with-command-line-arguments-and-goflags/
@@ -23,9 +28,14 @@ var opts struct {
 func main() {
 
 	p := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+	p.AddGroup("Global Options", "", utils.Settings)
 
 	aws.AddCommand(p)
 	azure.AddCommand(p)
+	templates.AddCommand(p)
+	backups.AddCommand(p)
+	clean.AddCommand(p)
+	validate.AddCommand(p)
 
 	_, err := p.Parse()
 
@@ -44,5 +54,19 @@ func main() {
 		aws.Init()
 	case "azure":
 		azure.Init()
+	case "templates":
+		templates.Init()
+	case "backups":
+		backups.Init()
+	case "restore":
+		backups.Restore()
+	case "clean":
+		clean.Init()
+	case "validate":
+		validate.Init()
+	}
+
+	if utils.Settings.DryRun && utils.PendingChanges {
+		os.Exit(utils.ExitChangesPending)
 	}
 }
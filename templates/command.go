@@ -0,0 +1,20 @@
+package templates
+
+import "github.com/jessevdk/go-flags"
+
+var (
+	command     *flags.Command
+	dumpOptions *DumpOptions
+)
+
+type DumpOptions struct {
+	Directory string `long:"dir" short:"d" default:"aiphelper-templates" description:"Directory to write the built-in templates to, usable with --template-dir"`
+	Overwrite bool   `long:"overwrite" description:"Overwrite templates that already exist in the directory"`
+}
+
+func AddCommand(p *flags.Parser) {
+	command, _ = p.AddCommand("templates", "Manage output templates", "Manage the templates used to render configuration files", &struct{}{})
+
+	dumpOptions = &DumpOptions{}
+	command.AddCommand("dump", "Export the built-in templates", "Export the built-in templates as a starting point for --template-dir", dumpOptions)
+}
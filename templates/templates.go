@@ -0,0 +1,53 @@
+package templates
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tamu-edu/aiphelper/aws"
+	"github.com/tamu-edu/aiphelper/azure"
+)
+
+func Init() {
+	switch command.Active.Name {
+	case "dump":
+		dump()
+	}
+}
+
+func dump() {
+	builtin := map[string]string{}
+	for name, contents := range aws.Templates() {
+		builtin[name] = contents
+	}
+	for name, contents := range azure.Templates() {
+		builtin[name] = contents
+	}
+
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dumpOptions.Directory, filepath.FromSlash(name))
+
+		if _, err := os.Stat(path); err == nil && !dumpOptions.Overwrite {
+			fmt.Printf("Skipping %s, which already exists\n", path)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatalln(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(builtin[name]), 0644); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ShardAggregator splits the named aggregator of connections into shards of at most size connections,
+// named like the aggregator with a number such as aws_all_01, and turns the aggregator into an
+// aggregator of its shards. The aggregator uses a wildcard for its shards unless the wildcard would
+// also match other connections, such as per-region aggregators. Connections are returned unchanged
+// when size is 0 or the aggregator has no more than size connections.
+func ShardAggregator(connections []HCLBlock, name string, size int) []HCLBlock {
+	for i, connection := range connections {
+		if connection.Name() != name {
+			continue
+		}
+		var sharded []HCLBlock
+		sharded = append(sharded, connections[:i]...)
+		sharded = append(sharded, shardAggregator(connection, size, connections)...)
+		return append(sharded, connections[i+1:]...)
+	}
+	return connections
+}
+
+func shardAggregator(aggregator HCLBlock, size int, others []HCLBlock) []HCLBlock {
+	connections := aggregatorConnections(aggregator)
+	if size <= 0 || len(connections) <= size {
+		return []HCLBlock{aggregator}
+	}
+
+	count := (len(connections) + size - 1) / size
+	width := len(strconv.Itoa(count))
+	if width < 2 {
+		width = 2
+	}
+
+	var shards []HCLBlock
+	shardNames := map[string]bool{}
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(connections) {
+			end = len(connections)
+		}
+		shard := withConnections(aggregator, connections[i*size:end])
+		shard.Labels = []string{fmt.Sprintf("%s_%0*d", aggregator.Name(), width, i+1)}
+		shard.Comments = nil
+		shards = append(shards, shard)
+		shardNames[shard.Name()] = true
+	}
+
+	wildcard := aggregator.Name() + "_*"
+	shardConnections := []string{wildcard}
+	for _, other := range others {
+		if ok, _ := path.Match(wildcard, other.Name()); ok && !shardNames[other.Name()] {
+			shardConnections = nil
+			for _, shard := range shards {
+				shardConnections = append(shardConnections, shard.Name())
+			}
+			break
+		}
+	}
+
+	return append([]HCLBlock{withConnections(aggregator, shardConnections)}, shards...)
+}
+
+// aggregatorConnections returns the connections attribute of an aggregator
+func aggregatorConnections(aggregator HCLBlock) []string {
+	value, ok := aggregator.Attribute("connections")
+	if !ok || value.IsNull() || !(value.Type().IsListType() || value.Type().IsTupleType()) {
+		return nil
+	}
+	var connections []string
+	for _, connection := range value.AsValueSlice() {
+		if connection.Type() == cty.String && !connection.IsNull() {
+			connections = append(connections, connection.AsString())
+		}
+	}
+	return connections
+}
+
+// withConnections returns a copy of an aggregator with its connections replaced
+func withConnections(aggregator HCLBlock, connections []string) HCLBlock {
+	attributes := make([]HCLAttribute, len(aggregator.Attributes))
+	for i, attribute := range aggregator.Attributes {
+		if attribute.Name == "connections" {
+			attribute = ListAttribute("connections", connections)
+		}
+		attributes[i] = attribute
+	}
+	aggregator.Attributes = attributes
+	return aggregator
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShardAggregator(t *testing.T) {
+	accounts := []HCLBlock{connection("aws_111", "aws", ""), connection("aws_222", "aws", ""), connection("aws_333", "aws", "")}
+
+	tests := []struct {
+		name        string
+		connections []HCLBlock
+		size        int
+		want        map[string][]string
+	}{
+		{
+			name:        "no shards",
+			connections: append([]HCLBlock{aggregator("aws_all", "aws", "aws_111", "aws_222", "aws_333")}, accounts...),
+			want:        map[string][]string{"aws_all": {"aws_111", "aws_222", "aws_333"}},
+		},
+		{
+			name:        "shards under a wildcard",
+			connections: append([]HCLBlock{aggregator("aws_all", "aws", "aws_111", "aws_222", "aws_333")}, accounts...),
+			size:        2,
+			want: map[string][]string{
+				"aws_all":    {"aws_all_*"},
+				"aws_all_01": {"aws_111", "aws_222"},
+				"aws_all_02": {"aws_333"},
+			},
+		},
+		{
+			name: "per-region aggregators listed by name",
+			connections: append([]HCLBlock{
+				aggregator("aws_all", "aws", "aws_111", "aws_222", "aws_333"),
+				aggregator("aws_all_us_east_1", "aws", "aws_111_us_east_1"),
+				connection("aws_111_us_east_1", "aws", ""),
+			}, accounts...),
+			size: 2,
+			want: map[string][]string{
+				"aws_all":           {"aws_all_01", "aws_all_02"},
+				"aws_all_01":        {"aws_111", "aws_222"},
+				"aws_all_02":        {"aws_333"},
+				"aws_all_us_east_1": {"aws_111_us_east_1"},
+			},
+		},
+		{
+			name: "account normalized to all listed by name",
+			connections: []HCLBlock{
+				aggregator("aws_all", "aws", "aws_111", "aws_all_all"),
+				connection("aws_111", "aws", ""),
+				connection("aws_all_all", "aws", ""),
+			},
+			size: 1,
+			want: map[string][]string{
+				"aws_all":    {"aws_all_01", "aws_all_02"},
+				"aws_all_01": {"aws_111"},
+				"aws_all_02": {"aws_all_all"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		sharded := ShardAggregator(test.connections, "aws_all", test.size)
+		got := map[string][]string{}
+		for _, connection := range sharded {
+			if connections := aggregatorConnections(connection); connections != nil {
+				got[connection.Name()] = connections
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got aggregators %v, want %v", test.name, got, test.want)
+		}
+		if errs := ValidateConnections(sharded, nil); len(errs) > 0 {
+			t.Errorf("%s: %v", test.name, errs)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// BackupTimeFormat is the format of the backup directory names
+const BackupTimeFormat = "20060102T150405Z"
+
+const backupIndexFile = "index.json"
+
+// Backup is a set of files saved by a single run, keyed by original path
+type Backup struct {
+	Timestamp string
+	Files     map[string]string
+}
+
+var runTimestamp = time.Now().UTC().Format(BackupTimeFormat)
+
+// restoringFrom is the backup that files are being restored from, which pruning keeps until the restore is done
+var restoringFrom string
+
+// BackupDir returns the directory backups are kept in
+func BackupDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".aiphelper/backups")
+}
+
+// backupFile saves the current contents of a file before it is overwritten
+func backupFile(path string, contents string) error {
+	if Settings.BackupRetention <= 0 {
+		return nil
+	}
+
+	dir := filepath.Join(BackupDir(), runTimestamp)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	backup, err := readBackup(runTimestamp)
+	if err != nil {
+		return err
+	}
+	if _, ok := backup.Files[path]; ok {
+		// keep the contents from before this run's first change
+		return nil
+	}
+
+	name := fmt.Sprintf("%d-%s", len(backup.Files), filepath.Base(path))
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+		return err
+	}
+	backup.Files[path] = name
+
+	index, err := json.MarshalIndent(backup.Files, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, backupIndexFile), index, 0600); err != nil {
+		return err
+	}
+
+	return pruneBackups()
+}
+
+func readBackup(timestamp string) (Backup, error) {
+	backup := Backup{Timestamp: timestamp, Files: map[string]string{}}
+
+	index, err := ioutil.ReadFile(filepath.Join(BackupDir(), timestamp, backupIndexFile))
+	if os.IsNotExist(err) {
+		return backup, nil
+	}
+	if err != nil {
+		return backup, err
+	}
+	err = json.Unmarshal(index, &backup.Files)
+	return backup, err
+}
+
+// ListBackups returns every backup, oldest first
+func ListBackups() ([]Backup, error) {
+	entries, err := ioutil.ReadDir(BackupDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		if _, err := time.Parse(BackupTimeFormat, entry.Name()); !entry.IsDir() || err != nil {
+			continue
+		}
+		backup, err := readBackup(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Timestamp < backups[j].Timestamp })
+	return backups, nil
+}
+
+// pruneBackups removes the oldest backups beyond the retention limit, except the backup being restored from
+func pruneBackups() error {
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+	for i := 0; len(backups) > Settings.BackupRetention && i < len(backups); {
+		if backups[i].Timestamp == restoringFrom {
+			i++
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(BackupDir(), backups[i].Timestamp)); err != nil {
+			return err
+		}
+		backups = append(backups[:i], backups[i+1:]...)
+	}
+	return nil
+}
+
+// RestoreFile writes the backed up contents of a file back to its original path. Backups are indexed by
+// the file that symlinks point to, so a symlink to a backed up file restores that file. The restore is
+// backed up like any other change, and the backup restored from is kept so the remaining files of the
+// same backup can be restored after it.
+func RestoreFile(backup Backup, path string) error {
+	restoringFrom = backup.Timestamp
+
+	target, err := resolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	name, ok := backup.Files[target]
+	if !ok {
+		return fmt.Errorf("backup %s does not contain %s", backup.Timestamp, path)
+	}
+
+	restored, err := ioutil.ReadFile(filepath.Join(BackupDir(), backup.Timestamp, name))
+	if err != nil {
+		return err
+	}
+
+	return UpdateFile(path, func(string) (string, error) {
+		return string(restored), nil
+	})
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRestoreFileThroughSymlink(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	previous := Settings
+	Settings = &Options{BackupRetention: 10}
+	t.Cleanup(func() { Settings = previous })
+
+	target := filepath.Join(home, "dotfiles", "config")
+	link := filepath.Join(home, "config")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(target, []byte("original\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	if err := CreateOrReplaceInFile(link, "aws:a", "generated"); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("%d backups, want 1", len(backups))
+	}
+
+	if err := RestoreFile(backups[0], link); err != nil {
+		t.Fatalf("restoring through the symlink: %v", err)
+	}
+	contents, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "original\n" {
+		t.Errorf("restored %q, want %q", contents, "original\n")
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink", link)
+	}
+}
+
+func TestRestoreOldestBackupAtRetentionLimit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	previous, previousTimestamp := Settings, runTimestamp
+	Settings = &Options{BackupRetention: 2}
+	t.Cleanup(func() { Settings, runTimestamp, restoringFrom = previous, previousTimestamp, "" })
+
+	a, b := filepath.Join(home, "a"), filepath.Join(home, "b")
+	for _, backup := range []struct {
+		timestamp string
+		contents  string
+	}{
+		{"20260101T000000Z", "oldest"},
+		{"20260102T000000Z", "newer"},
+	} {
+		runTimestamp = backup.timestamp
+		for _, path := range []string{a, b} {
+			if err := backupFile(path, backup.contents+"\n"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, path := range []string{a, b} {
+		if err := ioutil.WriteFile(path, []byte("current\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("%d backups, want 2", len(backups))
+	}
+
+	runTimestamp = "20260103T000000Z"
+	for _, path := range []string{a, b} {
+		if err := RestoreFile(backups[0], path); err != nil {
+			t.Fatalf("restoring %s: %v", path, err)
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "oldest\n" {
+			t.Errorf("restored %s to %q, want %q", path, contents, "oldest\n")
+		}
+	}
+
+	backups, err = ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var timestamps []string
+	for _, backup := range backups {
+		timestamps = append(timestamps, backup.Timestamp)
+	}
+	if want := []string{"20260101T000000Z", "20260103T000000Z"}; !reflect.DeepEqual(timestamps, want) {
+		t.Errorf("backups after the restore: %v, want %v", timestamps, want)
+	}
+}
@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var markerPattern = regexp.MustCompile(`^### ` + Marker + `_(START|END)(?: ([^\s#=]+))?(?: sha256=([0-9a-f]+))? ###$`)
+
+// Block is a managed block in a file. Blocks written before blocks were named have an empty name,
+// and blocks written before hashes were added have an empty hash.
+type Block struct {
+	Name  string
+	Hash  string
+	Begin int
+	End   int
+}
+
+// BlockName builds a block name such as aws:aggie-innovation-platform from a provider and an instance
+func BlockName(provider string, instance string) string {
+	return provider + ":" + regexp.MustCompile(`[^A-Za-z0-9._:-]+`).ReplaceAllString(instance, "-")
+}
+
+// StartMarker returns the line that begins the named block, including the hash of its content when one is given
+func StartMarker(name string, hash string) string {
+	marker := Marker + "_START"
+	if name != "" {
+		marker += " " + name
+	}
+	if hash != "" {
+		marker += " sha256=" + hash
+	}
+	return fmt.Sprintf("### %s ###", marker)
+}
+
+// EndMarker returns the line that ends the named block
+func EndMarker(name string) string {
+	if name == "" {
+		return fmt.Sprintf("### %s_END ###", Marker)
+	}
+	return fmt.Sprintf("### %s_END %s ###", Marker, name)
+}
+
+// parseMarker returns the kind (START or END), block name and content hash of a marker line
+func parseMarker(line string) (string, string, string, bool) {
+	match := markerPattern.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+	if match == nil {
+		return "", "", "", false
+	}
+	return match[1], match[2], match[3], true
+}
+
+// blockHash returns the hash of the lines inside a block, which is stored in its START marker
+func blockHash(inner []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(inner, "\n")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// FindBlocks returns the complete managed blocks in lines. Each END marker is paired with the closest START marker
+// of the same name before it, so an unterminated block left above a block appended later is not mistaken for its start.
+func FindBlocks(lines []string) []Block {
+	var blocks []Block
+	open := map[string]Block{}
+
+	for i, line := range lines {
+		kind, name, hash, ok := parseMarker(line)
+		if !ok {
+			continue
+		}
+		block, isOpen := open[name]
+		switch {
+		case kind == "START":
+			open[name] = Block{Name: name, Hash: hash, Begin: i}
+		case kind == "END" && isOpen:
+			block.End = i
+			blocks = append(blocks, block)
+			delete(open, name)
+		}
+	}
+
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Begin < blocks[j].Begin })
+	return blocks
+}
+
+func findBlock(lines []string, name string) (Block, bool) {
+	for _, block := range FindBlocks(lines) {
+		if block.Name == name {
+			return block, true
+		}
+	}
+	return Block{}, false
+}
+
+// targetBlock finds the block that a named block replaces. An unnamed block from an older version
+// is migrated to the first name written to the file.
+func targetBlock(lines []string, name string) (Block, bool) {
+	if block, ok := findBlock(lines, name); ok {
+		return block, true
+	}
+	return findBlock(lines, "")
+}
+
+// nameBlock rewrites the outermost markers of a rendered block with the block's name and content hash,
+// adding them if the template left them out
+func nameBlock(lines []string, name string) []string {
+	begin, end := -1, -1
+	for i, line := range lines {
+		kind, _, _, ok := parseMarker(line)
+		if ok && kind == "START" && begin == -1 {
+			begin = i
+		}
+		if ok && kind == "END" {
+			end = i
+		}
+	}
+
+	if begin == -1 || end == -1 || end < begin {
+		named := append([]string{StartMarker(name, blockHash(lines))}, lines...)
+		return append(named, EndMarker(name))
+	}
+
+	named := append([]string{}, lines...)
+	named[begin] = StartMarker(name, blockHash(lines[begin+1:end]))
+	named[end] = EndMarker(name)
+	return named
+}
+
+// EditedBlock returns the current text of the block that the named block would replace if it was
+// changed by hand since it was written
+func EditedBlock(fileContents string, name string) (string, bool) {
+	lines, _ := splitLines(fileContents)
+
+	block, ok := targetBlock(lines, name)
+	if !ok || block.Hash == "" || block.Hash == blockHash(lines[block.Begin+1:block.End]) {
+		return "", false
+	}
+	return strings.Join(lines[block.Begin:block.End+1], "\n"), true
+}
+
+// blockLines returns the lines of the named block without the section's trailing newline, which is
+// written after the END marker instead
+func blockLines(name string, section string) []string {
+	lines, _ := splitLines(section)
+	lines = nameBlock(lines, name)
+	for len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// RenderBlock returns the block as it will be written to a file
+func RenderBlock(name string, section string) string {
+	return strings.Join(blockLines(name, section), "\n")
+}
+
+func splitLines(fileContents string) ([]string, bool) {
+	crlf := strings.Contains(fileContents, "\r\n")
+	return strings.Split(strings.ReplaceAll(fileContents, "\r\n", "\n"), "\n"), crlf
+}
+
+func joinLines(lines []string, crlf bool) string {
+	if crlf {
+		return strings.Join(lines, "\r\n")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ReplaceInString replaces the named block in fileContents with newSection, or appends newSection when
+// there is no block. Content outside the block and the file's line endings are preserved, and a block at
+// the end of the file is followed by a newline.
+func ReplaceInString(fileContents string, name string, newSection string) (string, error) {
+	lines, crlf := splitLines(fileContents)
+	newLines := blockLines(name, newSection)
+
+	var newFileContents []string
+	var contentAfter []string
+	if block, ok := targetBlock(lines, name); ok {
+		// Replace block in file
+		newFileContents = append(newFileContents, lines[:block.Begin]...)
+		newFileContents = append(newFileContents, newLines...)
+		contentAfter = lines[block.End+1:]
+	} else {
+		// Append to file
+		newFileContents = append(newFileContents, lines...)
+		newFileContents = append(newFileContents, newLines...)
+	}
+
+	if len(contentAfter) == 0 {
+		contentAfter = []string{""}
+	}
+	newFileContents = append(newFileContents, contentAfter...)
+
+	return joinLines(newFileContents, crlf), nil
+}
+
+// RemoveFromString removes the named block from fileContents
+func RemoveFromString(fileContents string, name string) string {
+	lines, crlf := splitLines(fileContents)
+
+	block, ok := findBlock(lines, name)
+	if !ok {
+		return fileContents
+	}
+	return joinLines(append(lines[:block.Begin:block.Begin], lines[block.End+1:]...), crlf)
+}
+
+// OutsideBlock returns the contents without the block that the named block would replace
+func OutsideBlock(fileContents string, name string) string {
+	lines, crlf := splitLines(fileContents)
+
+	block, ok := targetBlock(lines, name)
+	if !ok {
+		return fileContents
+	}
+	return joinLines(append(lines[:block.Begin:block.Begin], lines[block.End+1:]...), crlf)
+}
@@ -0,0 +1,402 @@
+package utils
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// block renders the named block around inner the way nameBlock writes it
+func block(name string, inner ...string) string {
+	return strings.Join(append(append([]string{StartMarker(name, blockHash(inner))}, inner...), EndMarker(name)), "\n")
+}
+
+func TestReplaceInString(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		section  string
+		want     string
+	}{
+		{
+			name:     "empty file",
+			contents: "",
+			section:  "new",
+			want:     "\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "append after content",
+			contents: "before\n",
+			section:  "new",
+			want:     "before\n\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "append after a last line without a newline",
+			contents: "before",
+			section:  "new",
+			want:     "before\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "replace keeps content before and after",
+			contents: "before\n" + block("aws:a", "old") + "\nafter\nlast",
+			section:  "new",
+			want:     "before\n" + block("aws:a", "new") + "\nafter\nlast",
+		},
+		{
+			name:     "replace at the end of the file adds a newline",
+			contents: "before\n" + block("aws:a", "old"),
+			section:  "new",
+			want:     "before\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "section with a trailing newline",
+			contents: block("aws:a", "old") + "\n",
+			section:  "new\n",
+			want:     block("aws:a", "new", "") + "\n",
+		},
+		{
+			name:     "template markers are named",
+			contents: "",
+			section:  "### AIPHELPER_MARKER_START ###\nnew\n### AIPHELPER_MARKER_END ###\n",
+			want:     "\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "other blocks are left alone",
+			contents: block("azure:b", "other") + "\n" + block("aws:a", "old") + "\n",
+			section:  "new",
+			want:     block("azure:b", "other") + "\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "unnamed block is migrated",
+			contents: "before\n### AIPHELPER_MARKER_START ###\nold\n### AIPHELPER_MARKER_END ###\nafter\n",
+			section:  "new",
+			want:     "before\n" + block("aws:a", "new") + "\nafter\n",
+		},
+		{
+			name:     "missing END marker appends",
+			contents: "### AIPHELPER_MARKER_START aws:a ###\nold\n",
+			section:  "new",
+			want:     "### AIPHELPER_MARKER_START aws:a ###\nold\n\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "END marker of another block appends",
+			contents: "### AIPHELPER_MARKER_START aws:a ###\nold\n### AIPHELPER_MARKER_END azure:b ###\n",
+			section:  "new",
+			want:     "### AIPHELPER_MARKER_START aws:a ###\nold\n### AIPHELPER_MARKER_END azure:b ###\n\n" + block("aws:a", "new") + "\n",
+		},
+		{
+			name:     "CRLF stays CRLF",
+			contents: "before\r\n" + strings.ReplaceAll(block("aws:a", "old"), "\n", "\r\n") + "\r\nafter\r\n",
+			section:  "new\nlines",
+			want:     "before\r\n" + strings.ReplaceAll(block("aws:a", "new", "lines"), "\n", "\r\n") + "\r\nafter\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		got, err := ReplaceInString(test.contents, "aws:a", test.section)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", test.name, got, test.want)
+		}
+
+		again, _ := ReplaceInString(got, "aws:a", test.section)
+		if again != got {
+			t.Errorf("%s: not idempotent:\nfirst  %q\nsecond %q", test.name, got, again)
+		}
+	}
+}
+
+func TestRemoveFromString(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{
+			name:     "removes the block",
+			contents: "before\n" + block("aws:a", "old") + "\nafter\nlast",
+			want:     "before\nafter\nlast",
+		},
+		{
+			name:     "keeps other blocks",
+			contents: block("azure:b", "other") + "\n" + block("aws:a", "old") + "\n",
+			want:     block("azure:b", "other") + "\n",
+		},
+		{
+			name:     "no block",
+			contents: "before\nafter\n",
+			want:     "before\nafter\n",
+		},
+		{
+			name:     "unterminated block is left alone",
+			contents: "### AIPHELPER_MARKER_START aws:a ###\nold\n",
+			want:     "### AIPHELPER_MARKER_START aws:a ###\nold\n",
+		},
+		{
+			name:     "CRLF stays CRLF",
+			contents: "before\r\n" + strings.ReplaceAll(block("aws:a", "old"), "\n", "\r\n") + "\r\nafter\r\n",
+			want:     "before\r\nafter\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		if got := RemoveFromString(test.contents, "aws:a"); got != test.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFindBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []Block
+	}{
+		{
+			name:     "named and unnamed blocks",
+			contents: "a\n### AIPHELPER_MARKER_START ###\nb\n### AIPHELPER_MARKER_END ###\n### AIPHELPER_MARKER_START aws:a sha256=0123abcd ###\n### AIPHELPER_MARKER_END aws:a ###",
+			want: []Block{
+				{Name: "", Begin: 1, End: 3},
+				{Name: "aws:a", Hash: "0123abcd", Begin: 4, End: 5},
+			},
+		},
+		{
+			name:     "interleaved blocks are sorted by their START marker",
+			contents: "### AIPHELPER_MARKER_START aws:a ###\n### AIPHELPER_MARKER_START azure:b ###\n### AIPHELPER_MARKER_END azure:b ###\n### AIPHELPER_MARKER_END aws:a ###",
+			want: []Block{
+				{Name: "aws:a", Begin: 0, End: 3},
+				{Name: "azure:b", Begin: 1, End: 2},
+			},
+		},
+		{
+			name:     "unterminated block",
+			contents: "### AIPHELPER_MARKER_START aws:a ###\nb\n",
+		},
+		{
+			name:     "unterminated block before a complete one",
+			contents: "### AIPHELPER_MARKER_START aws:a ###\nold\n### AIPHELPER_MARKER_START aws:a ###\nnew\n### AIPHELPER_MARKER_END aws:a ###",
+			want:     []Block{{Name: "aws:a", Begin: 2, End: 4}},
+		},
+		{
+			name:     "END before START",
+			contents: "### AIPHELPER_MARKER_END aws:a ###\n### AIPHELPER_MARKER_START aws:a ###\n",
+		},
+		{
+			name:     "CRLF markers",
+			contents: "### AIPHELPER_MARKER_START aws:a ###\r\nb\r\n### AIPHELPER_MARKER_END aws:a ###\r\n",
+			want:     []Block{{Name: "aws:a", Begin: 0, End: 2}},
+		},
+		{
+			name:     "markers must be whole lines",
+			contents: "# ### AIPHELPER_MARKER_START aws:a ###\n### AIPHELPER_MARKER_END aws:a ###",
+		},
+	}
+
+	for _, test := range tests {
+		got := FindBlocks(strings.Split(test.contents, "\n"))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNameBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "markers are added",
+			lines: []string{"", "content"},
+			want:  []string{StartMarker("aws:a", blockHash([]string{"", "content"})), "", "content", EndMarker("aws:a")},
+		},
+		{
+			name:  "template markers are named",
+			lines: []string{"# header", "### AIPHELPER_MARKER_START ###", "content", "### AIPHELPER_MARKER_END ###", ""},
+			want:  []string{"# header", StartMarker("aws:a", blockHash([]string{"content"})), "content", EndMarker("aws:a"), ""},
+		},
+		{
+			name:  "outermost markers are named",
+			lines: []string{"### AIPHELPER_MARKER_START ###", "### AIPHELPER_MARKER_START ###", "### AIPHELPER_MARKER_END ###", "### AIPHELPER_MARKER_END ###"},
+			want: []string{
+				StartMarker("aws:a", blockHash([]string{"### AIPHELPER_MARKER_START ###", "### AIPHELPER_MARKER_END ###"})),
+				"### AIPHELPER_MARKER_START ###", "### AIPHELPER_MARKER_END ###",
+				EndMarker("aws:a"),
+			},
+		},
+		{
+			name:  "END before START",
+			lines: []string{"### AIPHELPER_MARKER_END ###", "### AIPHELPER_MARKER_START ###"},
+			want: []string{
+				StartMarker("aws:a", blockHash([]string{"### AIPHELPER_MARKER_END ###", "### AIPHELPER_MARKER_START ###"})),
+				"### AIPHELPER_MARKER_END ###", "### AIPHELPER_MARKER_START ###",
+				EndMarker("aws:a"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if got := nameBlock(test.lines, "aws:a"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+// plainLines turns random strings into lines without line breaks
+func plainLines(lines []string) []string {
+	plain := make([]string, len(lines))
+	for i, line := range lines {
+		plain[i] = strings.NewReplacer("\r", " ", "\n", " ").Replace(line)
+	}
+	return plain
+}
+
+func TestReplaceInStringIdempotent(t *testing.T) {
+	property := func(contents string, section string) bool {
+		once, _ := ReplaceInString(contents, "aws:a", section)
+		twice, _ := ReplaceInString(once, "aws:a", section)
+		return once == twice
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReplaceInStringPreservesOutside(t *testing.T) {
+	property := func(before []string, old []string, after []string, section string, crlf bool) bool {
+		before, old, after = plainLines(before), plainLines(old), plainLines(after)
+
+		lines := append(append(append([]string{}, before...), block("aws:a", old...)), after...)
+		contents := joinLines(strings.Split(strings.Join(lines, "\n"), "\n"), crlf)
+
+		got, _ := ReplaceInString(contents, "aws:a", section)
+
+		newline := "\n"
+		if crlf {
+			newline = "\r\n"
+		}
+		if crlf && strings.Count(got, "\n") != strings.Count(got, "\r\n") {
+			return false
+		}
+		if len(before) > 0 && !strings.HasPrefix(got, joinLines(before, crlf)+newline) {
+			return false
+		}
+		if len(after) > 0 {
+			return strings.HasSuffix(got, newline+joinLines(after, crlf))
+		}
+		return strings.HasSuffix(got, EndMarker("aws:a")+newline)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRemoveFromStringUndoesReplace(t *testing.T) {
+	property := func(before []string, after []string, section string) bool {
+		before, after = plainLines(before), plainLines(after)
+
+		contents := strings.Join(append(append(append([]string{}, before...), block("aws:a", "old")), after...), "\n")
+		replaced, _ := ReplaceInString(contents, "aws:a", section)
+
+		want := strings.Join(append(append([]string{}, before...), after...), "\n")
+		if len(after) == 0 {
+			want = strings.Join(append(append([]string{}, before...), ""), "\n")
+		}
+		return RemoveFromString(replaced, "aws:a") == want
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEditedBlock(t *testing.T) {
+	written, _ := ReplaceInString("before\n", "aws:a", "one\ntwo")
+	edited := strings.Replace(written, "two", "changed", 1)
+	crlf := func(s string) string { return strings.ReplaceAll(s, "\n", "\r\n") }
+
+	tests := []struct {
+		name     string
+		contents string
+		want     bool
+	}{
+		{"as written", written, false},
+		{"as written with CRLF line endings", crlf(written), false},
+		{"edited", edited, true},
+		{"edited with CRLF line endings", crlf(edited), true},
+		{"no hash from an older version", "before\n" + StartMarker("aws:a", "") + "\nchanged\n" + EndMarker("aws:a") + "\n", false},
+		{"no block", "before\n", false},
+	}
+
+	for _, test := range tests {
+		block, ok := EditedBlock(test.contents, "aws:a")
+		if ok != test.want {
+			t.Errorf("%s: EditedBlock() = %v, want %v", test.name, ok, test.want)
+			continue
+		}
+		if ok && (strings.Contains(block, "\r") || !strings.Contains(block, "changed") || strings.Contains(block, "before")) {
+			t.Errorf("%s: EditedBlock() returned %q, want the edited block alone", test.name, block)
+		}
+	}
+}
+
+func TestCreateOrReplaceEditedBlock(t *testing.T) {
+	written, _ := ReplaceInString("before\n", "aws:a", "one\ntwo")
+	edited := strings.ReplaceAll(strings.Replace(written, "two", "changed", 1), "\n", "\r\n")
+	replaced, _ := ReplaceInString(edited, "aws:a", "new")
+
+	tests := []struct {
+		name      string
+		settings  Options
+		wantErr   bool
+		want      string
+		sideFiles int
+	}{
+		{name: "refuse", settings: Options{}, wantErr: true, want: edited},
+		{name: "force", settings: Options{Force: true}, want: replaced},
+		{name: "save edits", settings: Options{SaveEdits: true}, want: replaced, sideFiles: 1},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		t.Setenv("HOME", dir)
+		previous := Settings
+		settings := test.settings
+		Settings = &settings
+		t.Cleanup(func() { Settings = previous })
+
+		path := filepath.Join(dir, "config")
+		if err := ioutil.WriteFile(path, []byte(edited), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := CreateOrReplaceInFile(path, "aws:a", "new")
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: CreateOrReplaceInFile() error = %v, want error %v", test.name, err, test.wantErr)
+		}
+		contents, _ := ioutil.ReadFile(path)
+		if string(contents) != test.want {
+			t.Errorf("%s: file is %q, want %q", test.name, contents, test.want)
+		}
+		if _, ok := EditedBlock(string(contents), "aws:a"); ok != test.wantErr {
+			t.Errorf("%s: EditedBlock() after the write = %v, want %v", test.name, ok, test.wantErr)
+		}
+
+		sideFiles, _ := filepath.Glob(path + ".aws-a.edited-*")
+		if len(sideFiles) != test.sideFiles {
+			t.Errorf("%s: %d side files, want %d", test.name, len(sideFiles), test.sideFiles)
+			continue
+		}
+		if len(sideFiles) > 0 {
+			if saved, _ := ioutil.ReadFile(sideFiles[0]); !strings.Contains(string(saved), "changed") {
+				t.Errorf("%s: side file is %q, want the edited block", test.name, saved)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Conflict policies
+const (
+	ConflictWarn   = "warn"
+	ConflictSkip   = "skip"
+	ConflictRename = "rename"
+	ConflictFail   = "fail"
+)
+
+// RenameSuffix is appended to generated names that collide with user-managed ones under the rename policy
+const RenameSuffix = "_aiphelper"
+
+var (
+	iniSectionPattern    = regexp.MustCompile(`^\s*\[\s*([^\]]*?)\s*\]`)
+	spcConnectionPattern = regexp.MustCompile(`^\s*connection\s+"([^"]+)"`)
+)
+
+// ReadUnmanaged returns the contents of a file outside of the named block. Missing files are empty.
+func ReadUnmanaged(path string, name string) (string, error) {
+	fileContents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return OutsideBlock(string(fileContents), name), nil
+}
+
+// IniSections returns the section names of an INI file, such as "profile my_account"
+func IniSections(contents string) []string {
+	var sections []string
+	for _, line := range strings.Split(contents, "\n") {
+		if match := iniSectionPattern.FindStringSubmatch(line); match != nil {
+			sections = append(sections, strings.Join(strings.Fields(match[1]), " "))
+		}
+	}
+	return sections
+}
+
+// SteampipeConnections returns the connection names defined in Steampipe config contents
+func SteampipeConnections(contents string) []string {
+	var connections []string
+	for _, line := range strings.Split(contents, "\n") {
+		if match := spcConnectionPattern.FindStringSubmatch(line); match != nil {
+			connections = append(connections, match[1])
+		}
+	}
+	return connections
+}
+
+// CheckSteampipeConflicts reports generated connections that are also defined outside of the named
+// block, either elsewhere in spcFilePath or in any other .spc file in the same directory.
+// Connections cannot be skipped or renamed without breaking aggregators, so every policy other than
+// fail only warns.
+func CheckSteampipeConflicts(spcFilePath string, name string, generated string) error {
+	existing := map[string]string{}
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(spcFilePath), "*.spc"))
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		var contents string
+		if match == spcFilePath {
+			contents, err = ReadUnmanaged(match, name)
+		} else {
+			var fileContents []byte
+			fileContents, err = ioutil.ReadFile(match)
+			contents = string(fileContents)
+		}
+		if err != nil {
+			return err
+		}
+		for _, connection := range SteampipeConnections(contents) {
+			existing[connection] = match
+		}
+	}
+
+	var conflicts []string
+	for _, connection := range SteampipeConnections(generated) {
+		if path, ok := existing[connection]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("connection %q is also defined in %s", connection, path))
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if Settings.ConflictPolicy == ConflictFail {
+		return fmt.Errorf("conflicting Steampipe connections:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+	for _, conflict := range conflicts {
+		fmt.Printf("Warning: %s\n", conflict)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	colorReset = "\033[0m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// PrintDiff prints a unified diff between the old and new contents of a file, colored when writing to a terminal
+func PrintDiff(path string, oldContents string, newContents string) {
+	if oldContents == newContents {
+		fmt.Printf("No changes to %s\n", path)
+		return
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(oldContents),
+		B:        difflib.SplitLines(newContents),
+		FromFile: path,
+		ToFile:   path,
+		Context:  3,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	color := useColor()
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		switch {
+		case !color:
+			fmt.Print(line)
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Print(line)
+		case strings.HasPrefix(line, "+"):
+			fmt.Print(colorGreen + strings.TrimSuffix(line, "\n") + colorReset + "\n")
+		case strings.HasPrefix(line, "-"):
+			fmt.Print(colorRed + strings.TrimSuffix(line, "\n") + colorReset + "\n")
+		case strings.HasPrefix(line, "@@"):
+			fmt.Print(colorCyan + strings.TrimSuffix(line, "\n") + colorReset + "\n")
+		default:
+			fmt.Print(line)
+		}
+	}
+}
+
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
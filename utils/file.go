@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CreateOrReplaceInFile replaces the named block in a file, or appends it when the file has none.
+// Blocks that were edited by hand are only replaced with --force or --save-edits.
+func CreateOrReplaceInFile(path string, name string, replaceWith string) error {
+	return UpdateFile(path, func(fileContents string) (string, error) {
+		if edited, ok := EditedBlock(fileContents, name); ok {
+			if err := handleEditedBlock(path, name, edited, RenderBlock(name, replaceWith)); err != nil {
+				return "", err
+			}
+		}
+		return ReplaceInString(fileContents, name, replaceWith)
+	})
+}
+
+// handleEditedBlock shows how a hand-edited block will change and decides whether it may be overwritten
+func handleEditedBlock(path string, name string, edited string, generated string) error {
+	fmt.Printf("Block %s in %s was edited since aiphelper last wrote it:\n", name, path)
+	PrintDiff(path, edited, generated)
+
+	switch {
+	case Settings.SaveEdits:
+		sideFile := fmt.Sprintf("%s.%s.edited-%s", path, strings.ReplaceAll(name, ":", "-"), runTimestamp)
+		if Settings.DryRun {
+			fmt.Printf("Dry run: would save the edited block to %s\n", sideFile)
+			return nil
+		}
+		if err := writeFileAtomic(sideFile, []byte(edited+"\n")); err != nil {
+			return err
+		}
+		fmt.Printf("Saved the edited block to %s\n", sideFile)
+		return nil
+	case Settings.Force:
+		return nil
+	default:
+		return fmt.Errorf("refusing to overwrite edited block %s in %s; use --force to discard the edits or --save-edits to keep a copy", name, path)
+	}
+}
+
+// RemoveFromFile removes the named block from a file
+func RemoveFromFile(path string, name string) error {
+	return UpdateFile(path, func(fileContents string) (string, error) {
+		return RemoveFromString(fileContents, name), nil
+	})
+}
+
+// RemoveBlocksFromFile removes the named blocks from a file, deleting the file when nothing but whitespace is left
+func RemoveBlocksFromFile(path string, names []string) error {
+	empty := false
+	err := UpdateFile(path, func(fileContents string) (string, error) {
+		for _, name := range names {
+			fileContents = RemoveFromString(fileContents, name)
+		}
+		empty = strings.TrimSpace(fileContents) == ""
+		return fileContents, nil
+	})
+	if err != nil || !empty {
+		return err
+	}
+	return removeFile(path, "which is now empty")
+}
+
+// WriteFile replaces the whole contents of a file that aiphelper alone writes, such as a kubeconfig file,
+// with the same locking, backups, --diff and --dry-run handling as managed blocks
+func WriteFile(path string, contents string) error {
+	return UpdateFile(path, func(string) (string, error) {
+		return contents, nil
+	})
+}
+
+// RemoveFile deletes a file that aiphelper alone writes, backing up its contents first
+func RemoveFile(path string) error {
+	if err := WriteFile(path, ""); err != nil {
+		return err
+	}
+	return removeFile(path, "which aiphelper wrote")
+}
+
+// removeFile deletes the file a path points to and its lock file, giving the reason in the message
+func removeFile(path string, reason string) error {
+	target, err := resolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if Settings.DryRun {
+		fmt.Printf("Dry run: would remove %s, %s\n", target, reason)
+		return nil
+	}
+	fmt.Printf("Removing %s, %s\n", target, reason)
+	os.Remove(lockFilePath(target))
+	return os.Remove(target)
+}
+
+// UpdateFile rewrites a file with the result of update while holding an advisory lock.
+// Symlinks are followed so the file they point to is updated rather than replaced.
+func UpdateFile(path string, update func(string) (string, error)) error {
+	target, err := resolveSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	if !Settings.DryRun {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		unlock, err := lockFile(target)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", target, err)
+		}
+		defer unlock()
+	}
+
+	fileContents, err := ioutil.ReadFile(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	output, err := update(string(fileContents))
+	if err != nil {
+		return err
+	}
+
+	return writeManagedFile(target, string(fileContents), output)
+}
+
+// writeManagedFile writes the new contents of a file, honoring the --diff and --dry-run options
+func writeManagedFile(path string, oldContents string, newContents string) error {
+	if Settings.Diff {
+		PrintDiff(path, oldContents, newContents)
+	}
+
+	if oldContents == newContents {
+		return nil
+	}
+	PendingChanges = true
+
+	if Settings.DryRun {
+		fmt.Printf("Dry run: would update %s\n", path)
+		return nil
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if err := backupFile(path, oldContents); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+
+	return writeFileAtomic(path, []byte(newContents))
+}
+
+// writeFileAtomic writes a temporary file next to path and renames it into place, keeping the mode
+// and owner of the existing file. New files are only readable by the current user.
+func writeFileAtomic(path string, contents []byte) error {
+	mode := os.FileMode(0600)
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if statErr == nil {
+		if err := chownLike(tmp.Name(), info); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockFilePath returns the lock file that guards updates to path
+func lockFilePath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+}
+
+// resolveSymlinks follows symlinks to the file they point to, which does not need to exist yet
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < 255; i++ {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+
+		link, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(path), link)
+		}
+		path = link
+	}
+	return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+}
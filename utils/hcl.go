@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// HCLBlock is a block of a generated Steampipe config file, such as a connection
+type HCLBlock struct {
+	Type       string
+	Labels     []string
+	Comments   []string
+	Attributes []HCLAttribute
+
+	// Source describes what the block was generated or read from, such as an account, for error messages
+	Source string
+}
+
+// HCLAttribute is an argument of an HCLBlock. Attributes are written in order.
+type HCLAttribute struct {
+	Name  string
+	Value cty.Value
+}
+
+// Name returns the first label of the block, which is the name of a connection
+func (b HCLBlock) Name() string {
+	if len(b.Labels) == 0 {
+		return ""
+	}
+	return b.Labels[0]
+}
+
+// Attribute returns the value of the named attribute
+func (b HCLBlock) Attribute(name string) (cty.Value, bool) {
+	for _, attribute := range b.Attributes {
+		if attribute.Name == name {
+			return attribute.Value, true
+		}
+	}
+	return cty.NilVal, false
+}
+
+// StringAttribute builds a string attribute
+func StringAttribute(name string, value string) HCLAttribute {
+	return HCLAttribute{Name: name, Value: cty.StringVal(value)}
+}
+
+// ListAttribute builds a list of strings attribute
+func ListAttribute(name string, values []string) HCLAttribute {
+	return HCLAttribute{Name: name, Value: StringList(values)}
+}
+
+// IntAttribute builds a number attribute
+func IntAttribute(name string, value int) HCLAttribute {
+	return HCLAttribute{Name: name, Value: cty.NumberIntVal(int64(value))}
+}
+
+// StringList converts a list of strings to a cty list
+func StringList(values []string) cty.Value {
+	if len(values) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+	list := make([]cty.Value, len(values))
+	for i, value := range values {
+		list[i] = cty.StringVal(value)
+	}
+	return cty.ListVal(list)
+}
+
+// ToCty converts the Go values used in templates and config files to cty values
+func ToCty(value interface{}) (cty.Value, error) {
+	switch v := value.(type) {
+	case cty.Value:
+		return v, nil
+	case string:
+		return cty.StringVal(v), nil
+	case *string:
+		if v == nil {
+			return cty.NullVal(cty.String), nil
+		}
+		return cty.StringVal(*v), nil
+	case []string:
+		return StringList(v), nil
+	case int:
+		return cty.NumberIntVal(int64(v)), nil
+	case int64:
+		return cty.NumberIntVal(v), nil
+	case bool:
+		return cty.BoolVal(v), nil
+	default:
+		return cty.NilVal, fmt.Errorf("cannot convert %T to an HCL value", value)
+	}
+}
+
+// HCLValue renders a value as an HCL expression, escaping quotes and template sequences in strings
+func HCLValue(value interface{}) (string, error) {
+	v, err := ToCty(value)
+	if err != nil {
+		return "", err
+	}
+	return string(hclwrite.TokensForValue(v).Bytes()), nil
+}
+
+// HCLQuote renders a string as a quoted HCL string
+func HCLQuote(s string) string {
+	return string(hclwrite.TokensForValue(cty.StringVal(s)).Bytes())
+}
+
+// RenderHCL renders blocks as formatted HCL, separated by blank lines
+func RenderHCL(blocks []HCLBlock) string {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+
+	for i, b := range blocks {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		for _, comment := range b.Comments {
+			comment = strings.Join(strings.Fields(comment), " ")
+			body.AppendUnstructuredTokens(hclwrite.Tokens{{Type: hclsyntax.TokenComment, Bytes: []byte("# " + comment + "\n")}})
+		}
+		block := body.AppendNewBlock(b.Type, b.Labels)
+		for _, attribute := range b.Attributes {
+			block.Body().SetAttributeValue(attribute.Name, attribute.Value)
+		}
+	}
+
+	return string(hclwrite.Format(f.Bytes()))
+}
+
+// ValidateHCL parses generated HCL to make sure it can be read back
+func ValidateHCL(filename string, src string) error {
+	_, diags := hclsyntax.ParseConfig([]byte(src), filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return fmt.Errorf("generated invalid HCL for %s: %w", filename, diags)
+	}
+	return nil
+}
+
+// HCLJoin quotes each value and joins them with commas, for use inside a list: ["a", "b"]
+func HCLJoin(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = HCLQuote(value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// HCLJoinInner is HCLJoin without the outermost quotes, for templates that write ["{{.RegionsString}}"]
+func HCLJoinInner(values []string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(HCLJoin(values), `"`), `"`)
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// parseList evaluates an HCL list of strings
+func parseList(t *testing.T, src string) []string {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "test.hcl", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		t.Fatalf("%s: %v", src, diags)
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		t.Fatalf("%s: %v", src, diags)
+	}
+	values := []string{}
+	for _, v := range value.AsValueSlice() {
+		values = append(values, v.AsString())
+	}
+	return values
+}
+
+func TestHCLJoinInner(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"us-east-1"}, `us-east-1`},
+		{[]string{"us-east-1", "us-west-2"}, `us-east-1", "us-west-2`},
+		{[]string{`"quoted"`}, `\"quoted\"`},
+		{[]string{`back\`}, `back\\`},
+		{[]string{"${var}", "%{if}"}, `$${var}", "%%{if}`},
+		{[]string{""}, ``},
+		{nil, ``},
+	}
+
+	for _, test := range tests {
+		got := HCLJoinInner(test.values)
+		if got != test.want {
+			t.Errorf("HCLJoinInner(%q) = %q, want %q", test.values, got, test.want)
+		}
+	}
+}
+
+// escapable maps a random string onto ASCII letters and the characters HCL strings escape. Other
+// Unicode is left out since cty normalizes strings to NFC.
+func escapable(s string) string {
+	const alphabet = "ab-_ \"\\\n\t${}%"
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteByte(alphabet[int(r)%len(alphabet)])
+	}
+	return b.String()
+}
+
+func TestHCLJoinInnerRoundTrip(t *testing.T) {
+	property := func(first string, rest []string) bool {
+		values := []string{escapable(first)}
+		for _, value := range rest {
+			values = append(values, escapable(value))
+		}
+		return reflect.DeepEqual(parseList(t, `["`+HCLJoinInner(values)+`"]`), values) &&
+			reflect.DeepEqual(parseList(t, `[`+HCLJoin(values)+`]`), values)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,312 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesAggregator aggregates the Kubernetes connections of every provider
+const KubernetesAggregator = "kubernetes_all"
+
+// kubernetesBlock names the blocks of kubernetes.spc and workspaces.spc with the kubernetes_all
+// aggregator, which is shared by every provider
+var kubernetesBlock = BlockName("steampipe", "kubernetes")
+
+// KubernetesCluster is a cluster to write a kubeconfig context and a Steampipe connection for. The
+// context, cluster and user of the kubeconfig are all named after the connection.
+type KubernetesCluster struct {
+	Connection string
+	Comments   []string
+	Source     string
+	Cluster    map[string]interface{}
+	User       map[string]interface{}
+}
+
+// Kubeconfig is the part of a kubeconfig file that aiphelper reads and writes
+type Kubeconfig struct {
+	APIVersion     string              `yaml:"apiVersion"`
+	Kind           string              `yaml:"kind"`
+	Clusters       []KubeconfigCluster `yaml:"clusters"`
+	Contexts       []KubeconfigContext `yaml:"contexts"`
+	Users          []KubeconfigUser    `yaml:"users"`
+	CurrentContext string              `yaml:"current-context,omitempty"`
+}
+
+type KubeconfigCluster struct {
+	Name    string                 `yaml:"name"`
+	Cluster map[string]interface{} `yaml:"cluster"`
+}
+
+type KubeconfigContext struct {
+	Name    string `yaml:"name"`
+	Context struct {
+		Cluster string `yaml:"cluster"`
+		User    string `yaml:"user"`
+	} `yaml:"context"`
+}
+
+type KubeconfigUser struct {
+	Name string                 `yaml:"name"`
+	User map[string]interface{} `yaml:"user"`
+}
+
+// ParseKubeconfig returns the cluster and user of the current context of a kubeconfig file, or of its
+// first context when none is current
+func ParseKubeconfig(contents []byte) (map[string]interface{}, map[string]interface{}, error) {
+	var kubeconfig Kubeconfig
+	if err := yaml.Unmarshal(contents, &kubeconfig); err != nil {
+		return nil, nil, err
+	}
+	if len(kubeconfig.Contexts) == 0 {
+		return nil, nil, fmt.Errorf("kubeconfig has no contexts")
+	}
+
+	context := kubeconfig.Contexts[0]
+	for _, c := range kubeconfig.Contexts {
+		if c.Name == kubeconfig.CurrentContext {
+			context = c
+		}
+	}
+
+	var cluster, user map[string]interface{}
+	for _, c := range kubeconfig.Clusters {
+		if c.Name == context.Context.Cluster {
+			cluster = c.Cluster
+		}
+	}
+	for _, u := range kubeconfig.Users {
+		if u.Name == context.Context.User {
+			user = u.User
+		}
+	}
+	if cluster == nil || user == nil {
+		return nil, nil, fmt.Errorf("kubeconfig context %q has no cluster or user", context.Name)
+	}
+	return cluster, user, nil
+}
+
+// KubernetesConfigFilePath returns the Steampipe file the Kubernetes connections are written to
+func KubernetesConfigFilePath() string {
+	return filepath.Join(SteampipeConfigDir(), "kubernetes.spc")
+}
+
+// KubeconfigFilePath returns the kubeconfig file of the named block, such as ~/.kube/aiphelper-aws-myorg-eks.yaml.
+// kubectl rewrites kubeconfig files without their comments, so every block has a whole file of its own
+// instead of a managed block.
+func KubeconfigFilePath(name string) string {
+	return filepath.Join(KubeconfigDir(), "aiphelper-"+strings.ReplaceAll(name, ":", "-")+".yaml")
+}
+
+// KubeconfigFilePaths returns the kubeconfig files aiphelper wrote for the provider
+func KubeconfigFilePaths(provider string) []string {
+	matches, _ := filepath.Glob(filepath.Join(KubeconfigDir(), "aiphelper-"+provider+"-*.yaml"))
+	return matches
+}
+
+// RenderKubeconfig renders a kubeconfig file with a context for each cluster
+func RenderKubeconfig(clusters []KubernetesCluster) (string, error) {
+	kubeconfig := Kubeconfig{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters:   []KubeconfigCluster{},
+		Contexts:   []KubeconfigContext{},
+		Users:      []KubeconfigUser{},
+	}
+	for _, cluster := range clusters {
+		context := KubeconfigContext{Name: cluster.Connection}
+		context.Context.Cluster = cluster.Connection
+		context.Context.User = cluster.Connection
+
+		kubeconfig.Clusters = append(kubeconfig.Clusters, KubeconfigCluster{Name: cluster.Connection, Cluster: cluster.Cluster})
+		kubeconfig.Contexts = append(kubeconfig.Contexts, context)
+		kubeconfig.Users = append(kubeconfig.Users, KubeconfigUser{Name: cluster.Connection, User: cluster.User})
+	}
+
+	contents, err := yaml.Marshal(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	return string(contents), nil
+}
+
+// KubernetesConnections builds a connection for the context of each cluster in the kubeconfig file
+func KubernetesConnections(kubeconfigPath string, clusters []KubernetesCluster) []HCLBlock {
+	var connections []HCLBlock
+	for _, cluster := range clusters {
+		connections = append(connections, HCLBlock{
+			Type:     "connection",
+			Labels:   []string{cluster.Connection},
+			Comments: cluster.Comments,
+			Attributes: []HCLAttribute{
+				StringAttribute("plugin", "kubernetes"),
+				StringAttribute("config_path", kubeconfigPath),
+				StringAttribute("config_context", cluster.Connection),
+			},
+			Source: cluster.Source,
+		})
+	}
+	return connections
+}
+
+// UpdateKubernetes writes the kubeconfig file and the Steampipe connections of the named block. The
+// connections are written to - (stdout) instead of kubernetes.spc when output is set, in which case the
+// kubernetes_all aggregator, workspaces and performance options are left alone. The connections are
+// checked before any file is written.
+func UpdateKubernetes(name string, clusters []KubernetesCluster, output string) error {
+	kubeconfigPath := KubeconfigFilePath(name)
+
+	kubeconfig, err := RenderKubeconfig(clusters)
+	if err != nil {
+		return err
+	}
+
+	connections := KubernetesConnections(kubeconfigPath, clusters)
+	section := "\n" + RenderHCL(connections)
+
+	spcFilePath := KubernetesConfigFilePath()
+
+	if err := ValidateSteampipeSection(spcFilePath, section, connections); err != nil {
+		return err
+	}
+
+	if output == "" {
+		if err := CheckSteampipeConflicts(spcFilePath, name, section); err != nil {
+			return err
+		}
+	}
+
+	if err := updateKubeconfig(kubeconfigPath, kubeconfig, len(clusters)); err != nil {
+		return err
+	}
+	if err := WriteOutput(output, spcFilePath, name, section); err != nil {
+		return err
+	}
+	if output != "" {
+		return nil
+	}
+
+	aggregator, err := UpdateKubernetesAggregator(connections)
+	if err != nil {
+		return err
+	}
+	profile, err := CurrentPerformanceProfile(spcFilePath, name, connections)
+	if err != nil {
+		return err
+	}
+	if err := UpdateKubernetesWorkspace(aggregator, profile); err != nil {
+		return err
+	}
+	if profile != nil {
+		fmt.Printf("Updating Steampipe %s profile for %d connections.\n", profile.Name, profile.Connections)
+	}
+	return UpdatePerformanceProfile(profile)
+}
+
+// updateKubeconfig writes the whole kubeconfig file, or removes it when there are no clusters
+func updateKubeconfig(path string, kubeconfig string, clusters int) error {
+	if clusters > 0 {
+		return WriteFile(path, kubeconfig)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	return RemoveFile(path)
+}
+
+// kubernetesAggregator builds the kubernetes_all aggregator, which matches the Kubernetes connections of
+// every provider with a wildcard
+func kubernetesAggregator() HCLBlock {
+	return HCLBlock{
+		Type:   "connection",
+		Labels: []string{KubernetesAggregator},
+		Attributes: []HCLAttribute{
+			StringAttribute("plugin", "kubernetes"),
+			StringAttribute("type", "aggregator"),
+			ListAttribute("connections", []string{"kubernetes_*"}),
+		},
+	}
+}
+
+// UpdateKubernetesAggregator writes the kubernetes_all aggregator while any managed block of kubernetes.spc has
+// connections, or the generated ones are about to be written, and removes it once none do. Connections
+// written by hand do not keep the aggregator. It returns the aggregator, or nil when it was removed.
+func UpdateKubernetesAggregator(generated []HCLBlock) ([]HCLBlock, error) {
+	spcFilePath := KubernetesConfigFilePath()
+
+	managed, err := managedKubernetesConnections(spcFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(generated) == 0 && managed == 0 {
+		return nil, removeBlockIfPresent(spcFilePath, kubernetesBlock)
+	}
+
+	aggregator := []HCLBlock{kubernetesAggregator()}
+	section := "\n" + RenderHCL(aggregator)
+
+	if err := ValidateSteampipeSection(spcFilePath, section, aggregator); err != nil {
+		return nil, err
+	}
+	if err := CheckSteampipeConflicts(spcFilePath, kubernetesBlock, section); err != nil {
+		return nil, err
+	}
+	return aggregator, CreateOrReplaceInFile(spcFilePath, kubernetesBlock, section)
+}
+
+// UpdateKubernetesWorkspace writes the kubernetes-all workspace of the aggregator, or removes it when the
+// aggregator is nil, and updates the all-clouds workspace to match
+func UpdateKubernetesWorkspace(aggregator []HCLBlock, profile *PerformanceProfile) error {
+	if aggregator == nil {
+		if err := removeBlockIfPresent(WorkspacesFilePath(), kubernetesBlock); err != nil {
+			return err
+		}
+		return UpdateAllCloudsWorkspace(nil, profile)
+	}
+
+	if Settings.SkipWorkspaces {
+		return nil
+	}
+	fmt.Println("Updating Steampipe workspaces.")
+	return UpdateWorkspaces(kubernetesBlock, AggregatorWorkspaces(aggregator), aggregator, profile)
+}
+
+// managedKubernetesConnections counts the connections of the managed blocks of kubernetes.spc other than the aggregator's
+func managedKubernetesConnections(spcFilePath string) (int, error) {
+	fileContents, err := ioutil.ReadFile(spcFilePath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	lines := strings.Split(string(fileContents), "\n")
+	for _, block := range FindBlocks(lines) {
+		if block.Name != kubernetesBlock {
+			count += len(SteampipeConnections(strings.Join(lines[block.Begin:block.End+1], "\n")))
+		}
+	}
+	return count, nil
+}
+
+// removeBlockIfPresent removes the named block from a file without creating the file when it is missing
+func removeBlockIfPresent(path string, name string) error {
+	fileContents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := findBlock(strings.Split(string(fileContents), "\n"), name); !ok {
+		return nil
+	}
+	return RemoveBlocksFromFile(path, []string{name})
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUpdateKubernetesValidatesFirst(t *testing.T) {
+	dir := t.TempDir()
+
+	previous := Settings
+	Settings = &Options{KubeconfigDir: filepath.Join(dir, "kube"), SteampipeConfigDir: filepath.Join(dir, "steampipe"), SteampipeProfile: ProfileOff}
+	t.Cleanup(func() { Settings = previous })
+
+	clusters := []KubernetesCluster{{
+		Connection: "Kubernetes-Invalid",
+		Cluster:    map[string]interface{}{"server": "https://example.edu"},
+		User:       map[string]interface{}{"token": "token"},
+	}}
+	if err := UpdateKubernetes("aws:test:eks", clusters, ""); err == nil {
+		t.Fatal("UpdateKubernetes accepted an invalid connection name")
+	}
+
+	for _, path := range []string{KubeconfigFilePath("aws:test:eks"), KubernetesConfigFilePath()} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was written although the connections are invalid", path)
+		}
+	}
+}
+
+func TestUpdateKubernetesWritesWholeKubeconfig(t *testing.T) {
+	dir := t.TempDir()
+
+	previous := Settings
+	Settings = &Options{KubeconfigDir: filepath.Join(dir, "kube"), SteampipeConfigDir: filepath.Join(dir, "steampipe"), SteampipeProfile: ProfileOff}
+	t.Cleanup(func() { Settings = previous })
+
+	clusters := []KubernetesCluster{{
+		Connection: "kubernetes_aws_123456789012_my_cluster",
+		Cluster:    map[string]interface{}{"server": "https://example.edu"},
+		User:       map[string]interface{}{"token": "token"},
+	}}
+	path := KubeconfigFilePath("aws:test:eks")
+	if err := UpdateKubernetes("aws:test:eks", clusters, ""); err != nil {
+		t.Fatal(err)
+	}
+	written, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), "AIPHELPER_MARKER") {
+		t.Errorf("kubeconfig has managed block markers:\n%s", written)
+	}
+	var kubeconfig Kubeconfig
+	if err := yaml.Unmarshal(written, &kubeconfig); err != nil {
+		t.Fatalf("kubeconfig is not YAML: %v", err)
+	}
+	if len(kubeconfig.Contexts) != 1 || kubeconfig.Contexts[0].Name != clusters[0].Connection {
+		t.Errorf("kubeconfig has contexts %v, want %s", kubeconfig.Contexts, clusters[0].Connection)
+	}
+
+	// kubectl config rewrites the file in its own layout, which the next run replaces
+	if err := ioutil.WriteFile(path, []byte("apiVersion: v1\nkind: Config\ncurrent-context: other\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateKubernetes("aws:test:eks", clusters, ""); err != nil {
+		t.Fatal(err)
+	}
+	if rewritten, _ := ioutil.ReadFile(path); string(rewritten) != string(written) {
+		t.Errorf("kubeconfig after a kubectl rewrite is\n%s\nwant\n%s", rewritten, written)
+	}
+
+	if err := UpdateKubernetes("aws:test:eks", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed when no clusters are left", path)
+	}
+}
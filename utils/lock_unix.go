@@ -0,0 +1,38 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive advisory lock on a lock file next to path, waiting for other aiphelper runs to finish
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(lockFilePath(path), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// chownLike gives path the owner and group of an existing file
+func chownLike(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	err := os.Chown(path, int(stat.Uid), int(stat.Gid))
+	if os.IsPermission(err) {
+		// only root can give files away; the file keeps the current user as owner
+		return nil
+	}
+	return err
+}
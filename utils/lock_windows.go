@@ -0,0 +1,31 @@
+//go:build windows
+
+package utils
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on a lock file next to path, waiting for other aiphelper runs to finish
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(lockFilePath(path), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	overlapped := &windows.Overlapped{}
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, overlapped)
+		f.Close()
+	}, nil
+}
+
+// chownLike is a no-op on Windows, which has no Unix owners to preserve
+func chownLike(path string, info os.FileInfo) error {
+	return nil
+}
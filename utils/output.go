@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// StdoutTarget is the --*-output value that writes a rendered block to standard output
+const StdoutTarget = "-"
+
+// Output receives rendered blocks written to standard output
+var Output io.Writer = os.Stdout
+
+// ProgressToStderr moves progress messages to standard error so standard output only contains rendered blocks
+func ProgressToStderr() {
+	Output = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+// WriteOutput writes a rendered block to its target: spliced into path when output is empty, to
+// standard output when output is "-", or as a complete standalone file at output otherwise
+func WriteOutput(output string, path string, name string, section string) error {
+	switch output {
+	case "":
+		return CreateOrReplaceInFile(path, name, section)
+	case StdoutTarget:
+		_, err := fmt.Fprintln(Output, RenderBlock(name, section))
+		return err
+	default:
+		return UpdateFile(ExpandHome(output), func(string) (string, error) {
+			return RenderBlock(name, section) + "\n", nil
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandHome replaces a leading ~ with the user's home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, path[1:])
+}
+
+// AWSConfigFile returns the AWS CLI config file, resolved like the AWS CLI does
+func AWSConfigFile() string {
+	if Settings.AWSConfigFile != "" {
+		return ExpandHome(Settings.AWSConfigFile)
+	}
+	return ExpandHome("~/.aws/config")
+}
+
+// SSOCacheDir returns the directory AWS SSO access tokens are cached in
+func SSOCacheDir() string {
+	if Settings.SSOCacheDir != "" {
+		return ExpandHome(Settings.SSOCacheDir)
+	}
+	return ExpandHome("~/.aws/sso/cache")
+}
+
+// SteampipeConfigDir returns the Steampipe config directory, resolved like Steampipe does
+func SteampipeConfigDir() string {
+	if Settings.SteampipeConfigDir != "" {
+		return ExpandHome(Settings.SteampipeConfigDir)
+	}
+	if installDir := os.Getenv("STEAMPIPE_INSTALL_DIR"); installDir != "" {
+		return filepath.Join(ExpandHome(installDir), "config")
+	}
+	return ExpandHome("~/.steampipe/config")
+}
+
+// FlowpipeConfigDir returns the Flowpipe config directory, honoring FLOWPIPE_INSTALL_DIR like Flowpipe does
+func FlowpipeConfigDir() string {
+	if Settings.FlowpipeConfigDir != "" {
+		return ExpandHome(Settings.FlowpipeConfigDir)
+	}
+	if installDir := os.Getenv("FLOWPIPE_INSTALL_DIR"); installDir != "" {
+		return filepath.Join(ExpandHome(installDir), "config")
+	}
+	return ExpandHome("~/.flowpipe/config")
+}
+
+// KubeconfigDir returns the directory kubeconfig files are written to
+func KubeconfigDir() string {
+	if Settings.KubeconfigDir != "" {
+		return ExpandHome(Settings.KubeconfigDir)
+	}
+	return ExpandHome("~/.kube")
+}
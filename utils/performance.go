@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Steampipe performance profiles
+const (
+	ProfileLaptop = "laptop"
+	ProfileServer = "server"
+	ProfileOff    = "off"
+)
+
+// performanceBlock names the block of default.spc with the generated options, which are shared by every provider
+var performanceBlock = BlockName("steampipe", "performance")
+
+var spcOptionsPattern = regexp.MustCompile(`^\s*options\s+"([^"]+)"`)
+
+// recordedProfilePattern matches the comment that records the profile in the options written to default.spc
+var recordedProfilePattern = regexp.MustCompile(`^# Steampipe ([a-z]+) profile for \d+ connections$`)
+
+// PerformanceProfile is the Steampipe tuning of the named profile for a number of connections
+type PerformanceProfile struct {
+	Name           string
+	Connections    int
+	CacheTTL       int
+	CacheMaxSizeMB int
+	MaxParallel    int
+	MemoryMaxMB    int
+	QueryTimeout   int
+}
+
+// profileLimits bounds the settings of a profile. Settings grow with the number of connections between the limits.
+type profileLimits struct {
+	minCacheTTL, maxCacheTTL                int
+	minCacheSizeMB, maxCacheSizeMB          int
+	minMaxParallel, maxMaxParallel          int
+	minMemoryMaxMB, maxMemoryMaxMB          int
+	minQueryTimeout, maxQueryTimeout        int
+	connectionsPerParallel, mbPerConnection int
+}
+
+var profiles = map[string]profileLimits{
+	ProfileLaptop: {
+		minCacheTTL: 300, maxCacheTTL: 900,
+		minCacheSizeMB: 256, maxCacheSizeMB: 1024,
+		minMaxParallel: 5, maxMaxParallel: 10,
+		minMemoryMaxMB: 1024, maxMemoryMaxMB: 4096,
+		minQueryTimeout: 120, maxQueryTimeout: 600,
+		connectionsPerParallel: 10, mbPerConnection: 16,
+	},
+	ProfileServer: {
+		minCacheTTL: 300, maxCacheTTL: 3600,
+		minCacheSizeMB: 1024, maxCacheSizeMB: 8192,
+		minMaxParallel: 10, maxMaxParallel: 50,
+		minMemoryMaxMB: 2048, maxMemoryMaxMB: 16384,
+		minQueryTimeout: 300, maxQueryTimeout: 1800,
+		connectionsPerParallel: 4, mbPerConnection: 32,
+	},
+}
+
+func clamp(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// NewPerformanceProfile scales the named profile to the number of connections. It returns nil when the profile is off.
+func NewPerformanceProfile(name string, connections int) *PerformanceProfile {
+	limits, ok := profiles[name]
+	if !ok {
+		return nil
+	}
+	return &PerformanceProfile{
+		Name:           name,
+		Connections:    connections,
+		CacheTTL:       clamp(limits.minCacheTTL+3*connections, limits.minCacheTTL, limits.maxCacheTTL),
+		CacheMaxSizeMB: clamp(limits.minCacheSizeMB+8*connections, limits.minCacheSizeMB, limits.maxCacheSizeMB),
+		MaxParallel:    clamp(connections/limits.connectionsPerParallel, limits.minMaxParallel, limits.maxMaxParallel),
+		MemoryMaxMB:    clamp(limits.minMemoryMaxMB+limits.mbPerConnection*connections, limits.minMemoryMaxMB, limits.maxMemoryMaxMB),
+		QueryTimeout:   clamp(limits.minQueryTimeout+2*connections, limits.minQueryTimeout, limits.maxQueryTimeout),
+	}
+}
+
+// selectedProfile returns --steampipe-profile, or the profile recorded in default.spc when it is not set, so
+// runs without the option and aiphelper clean keep the profile chosen last
+func selectedProfile() (string, error) {
+	if Settings.SteampipeProfile != "" {
+		return Settings.SteampipeProfile, nil
+	}
+
+	fileContents, err := ioutil.ReadFile(DefaultConfigFilePath())
+	if os.IsNotExist(err) {
+		return ProfileOff, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	lines, _ := splitLines(string(fileContents))
+	block, ok := findBlock(lines, performanceBlock)
+	if !ok {
+		return ProfileOff, nil
+	}
+	for _, line := range lines[block.Begin+1 : block.End] {
+		if m := recordedProfilePattern.FindStringSubmatch(line); m != nil {
+			if _, ok := profiles[m[1]]; ok {
+				return m[1], nil
+			}
+		}
+	}
+	return ProfileOff, nil
+}
+
+// CurrentPerformanceProfile scales the selected profile to the generated connections and the other connections
+// of the Steampipe config directory, leaving out the named block of spcFilePath, which the generated
+// connections replace. It returns nil when the profile is off.
+func CurrentPerformanceProfile(spcFilePath string, name string, generated []HCLBlock) (*PerformanceProfile, error) {
+	profile, err := selectedProfile()
+	if err != nil || profile == ProfileOff {
+		return nil, err
+	}
+	existing, err := steampipeConfigConnections(spcFilePath)
+	if err != nil {
+		return nil, err
+	}
+	count := len(existing) + len(generated)
+	if spcFilePath != "" {
+		unmanaged, err := ReadUnmanaged(spcFilePath, name)
+		if err != nil {
+			return nil, err
+		}
+		count += len(SteampipeConnections(unmanaged))
+	}
+	return NewPerformanceProfile(profile, count), nil
+}
+
+// tune adds the settings of the profile that Steampipe reads from workspaces to each workspace.
+// Workspaces are returned unchanged when the profile is nil.
+func (p *PerformanceProfile) tune(workspaces []HCLBlock) []HCLBlock {
+	if p == nil {
+		return workspaces
+	}
+	tuned := make([]HCLBlock, len(workspaces))
+	for i, workspace := range workspaces {
+		workspace.Attributes = append(append([]HCLAttribute{}, workspace.Attributes...),
+			IntAttribute("query_timeout", p.QueryTimeout),
+			IntAttribute("cache_ttl", p.CacheTTL),
+			IntAttribute("max_parallel", p.MaxParallel),
+		)
+		tuned[i] = workspace
+	}
+	return tuned
+}
+
+// optionsBlocks returns the database and plugin options of the profile. The comment records the profile for
+// later runs without --steampipe-profile.
+func (p *PerformanceProfile) optionsBlocks() []HCLBlock {
+	return []HCLBlock{
+		{
+			Type:     "options",
+			Labels:   []string{"database"},
+			Comments: []string{fmt.Sprintf("Steampipe %s profile for %d connections", p.Name, p.Connections)},
+			Attributes: []HCLAttribute{
+				{Name: "cache", Value: cty.True},
+				IntAttribute("cache_max_ttl", p.CacheTTL),
+				IntAttribute("cache_max_size_mb", p.CacheMaxSizeMB),
+			},
+		},
+		{
+			Type:   "options",
+			Labels: []string{"plugin"},
+			Attributes: []HCLAttribute{
+				IntAttribute("memory_max_mb", p.MemoryMaxMB),
+			},
+		},
+	}
+}
+
+// DefaultConfigFilePath returns the Steampipe file the performance options are written to
+func DefaultConfigFilePath() string {
+	return filepath.Join(SteampipeConfigDir(), "default.spc")
+}
+
+// UpdatePerformanceProfile writes the options of the profile to default.spc, or removes them when the profile is off
+func UpdatePerformanceProfile(profile *PerformanceProfile) error {
+	path := DefaultConfigFilePath()
+
+	if profile == nil {
+		return removeBlockIfPresent(path, performanceBlock)
+	}
+
+	if err := checkOptionsConflicts(path); err != nil {
+		return err
+	}
+
+	section := "\n" + RenderHCL(profile.optionsBlocks())
+	if err := ValidateHCL(path, section); err != nil {
+		return err
+	}
+	return CreateOrReplaceInFile(path, performanceBlock, section)
+}
+
+// UpdateSteampipeSettings writes the workspaces and performance options that go with the connections
+// generated for the named block of spcFilePath
+func UpdateSteampipeSettings(spcFilePath string, name string, connections []HCLBlock) error {
+	profile, err := CurrentPerformanceProfile(spcFilePath, name, connections)
+	if err != nil {
+		return err
+	}
+
+	if !Settings.SkipWorkspaces {
+		fmt.Println("Updating Steampipe workspaces.")
+		if err := UpdateWorkspaces(name, AggregatorWorkspaces(connections), connections, profile); err != nil {
+			return err
+		}
+	}
+
+	if profile != nil {
+		fmt.Printf("Updating Steampipe %s profile for %d connections.\n", profile.Name, profile.Connections)
+	}
+	return UpdatePerformanceProfile(profile)
+}
+
+// checkOptionsConflicts reports options blocks of the Steampipe config directory that the generated ones
+// would conflict with. Like connections, they cannot be skipped or renamed, so every policy other than
+// fail only warns.
+func checkOptionsConflicts(path string) error {
+	matches, err := filepath.Glob(filepath.Join(SteampipeConfigDir(), "*.spc"))
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+	for _, match := range matches {
+		var contents string
+		if match == path {
+			contents, err = ReadUnmanaged(match, performanceBlock)
+		} else {
+			var fileContents []byte
+			fileContents, err = ioutil.ReadFile(match)
+			contents = string(fileContents)
+		}
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(contents, "\n") {
+			if m := spcOptionsPattern.FindStringSubmatch(line); m != nil && (m[1] == "database" || m[1] == "plugin") {
+				conflicts = append(conflicts, fmt.Sprintf("options %q are also defined in %s", m[1], match))
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if Settings.ConflictPolicy == ConflictFail {
+		return fmt.Errorf("conflicting Steampipe options:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+	for _, conflict := range conflicts {
+		fmt.Printf("Warning: %s\n", conflict)
+	}
+	return nil
+}
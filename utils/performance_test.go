@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCurrentPerformanceProfileKeepsRecordedProfile(t *testing.T) {
+	previous := Settings
+	t.Cleanup(func() { Settings = previous })
+	dir := filepath.Join(t.TempDir(), "steampipe")
+
+	tests := []struct {
+		option string
+		want   string
+	}{
+		{"", ""},
+		{ProfileLaptop, ProfileLaptop},
+		{"", ProfileLaptop},
+		{ProfileServer, ProfileServer},
+		{"", ProfileServer},
+		{ProfileOff, ""},
+		{"", ""},
+	}
+	for i, test := range tests {
+		Settings = &Options{SteampipeConfigDir: dir, SteampipeProfile: test.option}
+
+		profile, err := CurrentPerformanceProfile("", "", []HCLBlock{{Type: "connection", Labels: []string{"aws_prod"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if profile != nil {
+			got = profile.Name
+		}
+		if got != test.want {
+			t.Errorf("run %d with --steampipe-profile=%q: profile %q, want %q", i, test.option, got, test.want)
+		}
+
+		if err := UpdatePerformanceProfile(profile); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package utils
+
+// Options shared by every command
+type Options struct {
+	DryRun              bool   `long:"dry-run" description:"Show which files would change without writing anything"`
+	Diff                bool   `long:"diff" description:"Print a unified diff of every file that is changed"`
+	Force               bool   `long:"force" description:"Overwrite managed blocks that were edited by hand"`
+	SaveEdits           bool   `long:"save-edits" description:"Save managed blocks that were edited by hand to a side file, then overwrite them"`
+	BackupRetention     int    `long:"backup-retention" default:"10" description:"Number of backups of changed files to keep in ~/.aiphelper/backups (0 disables backups)"`
+	AWSConfigFile       string `long:"aws-config-file" env:"AWS_CONFIG_FILE" description:"AWS CLI config file to write profiles to (default: ~/.aws/config)"`
+	SSOCacheDir         string `long:"sso-cache-dir" description:"Directory AWS SSO access tokens are cached in (default: ~/.aws/sso/cache)"`
+	SteampipeConfigDir  string `long:"steampipe-config-dir" description:"Steampipe config directory to write connections to (default: $STEAMPIPE_INSTALL_DIR/config or ~/.steampipe/config)"`
+	Flowpipe            bool   `long:"flowpipe" description:"Also write Flowpipe credentials for the accounts and subscriptions"`
+	FlowpipeConfigDir   string `long:"flowpipe-config-dir" description:"Flowpipe config directory to write credentials to (default: $FLOWPIPE_INSTALL_DIR/config or ~/.flowpipe/config)"`
+	Kubernetes          bool   `long:"kubernetes" description:"Also write kubeconfig contexts and Steampipe Kubernetes connections for the EKS and AKS clusters"`
+	KubeconfigDir       string `long:"kubeconfig-dir" description:"Directory to write kubeconfig files to (default: ~/.kube)"`
+	TemplateDir         string `long:"template-dir" description:"Directory with templates that override the built-in ones (see aiphelper templates dump)"`
+	SkipWorkspaces      bool   `long:"skip-workspaces" description:"Do not generate Steampipe workspaces for the generated aggregators in workspaces.spc"`
+	AggregatorShardSize int    `long:"aggregator-shard-size" description:"Split the aws_all and azure_all aggregators into aggregators of this many connections, such as aws_all_01 (default: no shards)"`
+	SteampipeProfile    string `long:"steampipe-profile" choice:"laptop" choice:"server" choice:"off" description:"Tune Steampipe for the number of generated connections in default.spc and workspaces.spc (default: the profile written last, or off)"`
+	ConflictPolicy      string `long:"conflict-policy" default:"warn" choice:"warn" choice:"skip" choice:"rename" choice:"fail" description:"How to handle generated profiles or connections that are also defined outside the aiphelper block"`
+}
+
+// ExitChangesPending is the exit code of a dry run that would have changed files
+const ExitChangesPending = 2
+
+var (
+	Settings = &Options{}
+
+	// PendingChanges is set when a managed file's contents differ from what was generated
+	PendingChanges = false
+)
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"golang.org/x/exp/slices"
+)
+
+// TemplateFuncs are the helper functions available to every template, including user-supplied ones
+var TemplateFuncs = template.FuncMap{
+	// join concatenates a list with a separator: {{.Regions | join ", "}}
+	"join": func(sep string, elems []string) string {
+		return strings.Join(elems, sep)
+	},
+	// quote returns a double-quoted HCL string with quotes, control characters and template sequences escaped
+	"quote": HCLQuote,
+	// hcl renders a string, list of strings, number or bool as an HCL expression: regions = {{hcl .SteampipeRegions}}
+	"hcl": HCLValue,
+	// snake normalizes a string the same way account and subscription names are normalized
+	"snake": SnakeCase,
+	// default returns the value, or def if the value is empty: {{.Region | default "us-east-1"}}
+	"default": func(def interface{}, value interface{}) interface{} {
+		if value == nil || reflect.ValueOf(value).IsZero() {
+			return def
+		}
+		return value
+	},
+	// hasTag reports whether a list of tags contains a tag: {{if hasTag .Tags "prod"}}
+	"hasTag": func(tags []string, tag string) bool {
+		return slices.Contains(tags, tag)
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// HasTemplateOverride reports whether the template directory overrides the named template
+func HasTemplateOverride(name string) bool {
+	if Settings.TemplateDir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(Settings.TemplateDir, filepath.FromSlash(name)))
+	return err == nil
+}
+
+// LoadTemplate parses the template called name from the template directory, falling back to the embedded template
+func LoadTemplate(name string, embedded string) *template.Template {
+	text := embedded
+
+	if Settings.TemplateDir != "" {
+		path := filepath.Join(Settings.TemplateDir, filepath.FromSlash(name))
+		contents, err := ioutil.ReadFile(path)
+		if err == nil {
+			fmt.Printf("Using template %s\n", path)
+			text = string(contents)
+		} else if !os.IsNotExist(err) {
+			log.Fatalln(err)
+		}
+	}
+
+	tmpl, err := template.New(name).Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		log.Fatalf("failed to parse template %s: %v", name, err)
+	}
+	return tmpl
+}
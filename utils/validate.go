@@ -0,0 +1,248 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// MaxConnectionNameLength is the longest connection name Steampipe accepts, the Postgres identifier limit
+const MaxConnectionNameLength = 63
+
+var connectionNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ShortenConnectionName keeps a generated connection name within MaxConnectionNameLength by truncating it
+// and appending a short hash of the full name, so the same name is always shortened the same way and
+// names with a common prefix stay distinct
+func ShortenConnectionName(name string) string {
+	if len(name) <= MaxConnectionNameLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:8]
+	return strings.TrimRight(name[:MaxConnectionNameLength-len(hash)-1], "_") + "_" + hash
+}
+
+// ValidateConnectionName checks a connection name against Steampipe's naming rules
+func ValidateConnectionName(name string) error {
+	return validateName("connection", name)
+}
+
+func validateName(kind string, name string) error {
+	if !connectionNamePattern.MatchString(name) {
+		return fmt.Errorf("%s name %q must start with a lowercase letter and only contain lowercase letters, digits and underscores", kind, name)
+	}
+	if len(name) > MaxConnectionNameLength {
+		return fmt.Errorf("%s name %q is longer than %d characters", kind, name, MaxConnectionNameLength)
+	}
+	return nil
+}
+
+// ValidateConnections checks the names of connections, that they are unique, and that aggregators only
+// reference connections that exist, either in connections or in known, and use the same plugin as the
+// connections in connections. Wildcard references are not checked since they may match no connection.
+// Every problem is reported, each with the source of the connection.
+func ValidateConnections(connections []HCLBlock, known []string) []error {
+	var errs []error
+
+	knownNames := map[string]bool{}
+	for _, name := range known {
+		knownNames[name] = true
+	}
+
+	defined := map[string]HCLBlock{}
+	for _, connection := range connections {
+		if err := ValidateConnectionName(connection.Name()); err != nil {
+			errs = append(errs, sourceError(connection, err))
+		}
+		if previous, ok := defined[connection.Name()]; ok {
+			message := fmt.Sprintf("connection %q is defined more than once", connection.Name())
+			if previous.Source != "" {
+				message += ", also by " + previous.Source
+			}
+			errs = append(errs, sourceError(connection, errors.New(message)))
+		}
+		defined[connection.Name()] = connection
+	}
+
+	for _, connection := range connections {
+		if !isAggregator(connection) {
+			continue
+		}
+		for _, reference := range aggregatorConnections(connection) {
+			if strings.Contains(reference, "*") {
+				if _, err := path.Match(reference, ""); err != nil {
+					errs = append(errs, sourceError(connection, fmt.Errorf("aggregator %q has an invalid connection pattern %q", connection.Name(), reference)))
+				}
+				continue
+			}
+			child, ok := defined[reference]
+			if !ok && !knownNames[reference] {
+				errs = append(errs, sourceError(connection, fmt.Errorf("aggregator %q references connection %q, which does not exist", connection.Name(), reference)))
+			}
+			if ok && plugin(child) != plugin(connection) {
+				errs = append(errs, sourceError(child, fmt.Errorf("connection %q uses plugin %q, but aggregator %q uses %q; an aggregator and its connections must use the same plugin", reference, plugin(child), connection.Name(), plugin(connection))))
+			}
+		}
+	}
+
+	return errs
+}
+
+func sourceError(block HCLBlock, err error) error {
+	if block.Source == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", block.Source, err)
+}
+
+// ValidationError combines the problems found by ValidateConnections
+func ValidationError(filename string, errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("invalid Steampipe connections for %s:\n  %s", filename, strings.Join(messages, "\n  "))
+}
+
+// ValidateSteampipeSection checks a rendered block of Steampipe connections before it is written to
+// spcFilePath. Connections take the source of the generated connection of the same name, so errors
+// name the account or subscription they were generated for. Aggregators may also reference
+// connections of the other Steampipe config files.
+func ValidateSteampipeSection(spcFilePath string, section string, generated []HCLBlock) error {
+	connections, err := ParseConnections(spcFilePath, section)
+	if err != nil {
+		return err
+	}
+
+	sources := map[string]string{}
+	for _, connection := range generated {
+		sources[connection.Name()] = connection.Source
+	}
+	for i, connection := range connections {
+		connections[i].Source = sources[connection.Name()]
+	}
+
+	var known []string
+	others, err := steampipeConfigConnections(spcFilePath)
+	if err != nil {
+		return err
+	}
+	for _, block := range others {
+		known = append(known, block.Labels[0])
+	}
+
+	return ValidationError(spcFilePath, ValidateConnections(connections, known))
+}
+
+// ParseConnections reads the connection blocks of Steampipe config contents. Each connection's source
+// is its position in the file, and attributes that are not literal values are left out.
+func ParseConnections(filename string, src string) ([]HCLBlock, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(src), filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid HCL in %s: %w", filename, diags)
+	}
+
+	var connections []HCLBlock
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "connection" {
+			continue
+		}
+
+		attributes := make([]*hclsyntax.Attribute, 0, len(block.Body.Attributes))
+		for _, attribute := range block.Body.Attributes {
+			attributes = append(attributes, attribute)
+		}
+		sort.Slice(attributes, func(i, j int) bool {
+			return attributes[i].SrcRange.Start.Byte < attributes[j].SrcRange.Start.Byte
+		})
+
+		connection := HCLBlock{
+			Type:   block.Type,
+			Labels: block.Labels,
+			Source: fmt.Sprintf("%s:%d", filename, block.DefRange().Start.Line),
+		}
+		if len(connection.Labels) == 0 {
+			connection.Labels = []string{""}
+		}
+		for _, attribute := range attributes {
+			if value, diags := attribute.Expr.Value(nil); !diags.HasErrors() {
+				connection.Attributes = append(connection.Attributes, HCLAttribute{Name: attribute.Name, Value: value})
+			}
+		}
+		connections = append(connections, connection)
+	}
+	return connections, nil
+}
+
+// ValidateSteampipeConfigDir checks every .spc file of the Steampipe config directory together, and
+// returns the number of files and connections that were checked
+func ValidateSteampipeConfigDir() (int, int, []error) {
+	matches, err := filepath.Glob(filepath.Join(SteampipeConfigDir(), "*.spc"))
+	if err != nil {
+		return 0, 0, []error{err}
+	}
+
+	var errs []error
+	var connections []HCLBlock
+	for _, match := range matches {
+		contents, err := ioutil.ReadFile(match)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fileConnections, err := ParseConnections(match, string(contents))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		connections = append(connections, fileConnections...)
+	}
+
+	return len(matches), len(connections), append(errs, ValidateConnections(connections, nil)...)
+}
+
+// ValidateFlowpipeSection checks a rendered block of Flowpipe credentials before it is written to
+// fpcFilePath. Credentials follow the same naming rules as Steampipe connections, and must be unique
+// for each type.
+func ValidateFlowpipeSection(fpcFilePath string, section string, credentials []HCLBlock) error {
+	if err := ValidateHCL(fpcFilePath, section); err != nil {
+		return err
+	}
+
+	var errs []error
+	defined := map[string]bool{}
+	for _, credential := range credentials {
+		name := credential.Labels[len(credential.Labels)-1]
+		if err := validateName("credential", name); err != nil {
+			errs = append(errs, sourceError(credential, err))
+		}
+		key := strings.Join(credential.Labels, ".")
+		if defined[key] {
+			errs = append(errs, sourceError(credential, fmt.Errorf("credential %q is defined more than once", key)))
+		}
+		defined[key] = true
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("invalid Flowpipe credentials for %s:\n  %s", fpcFilePath, strings.Join(messages, "\n  "))
+}
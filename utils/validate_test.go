@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func connection(name string, plugin string, source string) HCLBlock {
+	return HCLBlock{
+		Type:       "connection",
+		Labels:     []string{name},
+		Attributes: []HCLAttribute{StringAttribute("plugin", plugin)},
+		Source:     source,
+	}
+}
+
+func aggregator(name string, plugin string, connections ...string) HCLBlock {
+	return HCLBlock{
+		Type:   "connection",
+		Labels: []string{name},
+		Attributes: []HCLAttribute{
+			StringAttribute("plugin", plugin),
+			StringAttribute("type", "aggregator"),
+			ListAttribute("connections", connections),
+		},
+	}
+}
+
+func TestValidateConnections(t *testing.T) {
+	tests := []struct {
+		name        string
+		connections []HCLBlock
+		known       []string
+		want        []string
+	}{
+		{
+			name: "valid",
+			connections: []HCLBlock{
+				aggregator("aws_all", "aws", "aws_prod", "aws_dev", "aws_other"),
+				connection("aws_prod", "aws", ""),
+				connection("aws_dev", "aws", ""),
+			},
+			known: []string{"aws_other"},
+		},
+		{
+			name:        "invalid and long names",
+			connections: []HCLBlock{connection("AWS-prod", "aws", ""), connection("aws_"+strings.Repeat("a", 60), "aws", "")},
+			want:        []string{"must start with a lowercase letter", "longer than 63 characters"},
+		},
+		{
+			name:        "duplicate",
+			connections: []HCLBlock{connection("aws_prod", "aws", `account "Prod"`), connection("aws_prod", "aws", `account "Prod 2"`)},
+			want:        []string{`account "Prod 2": connection "aws_prod" is defined more than once, also by account "Prod"`},
+		},
+		{
+			name:        "missing reference",
+			connections: []HCLBlock{aggregator("aws_all", "aws", "aws_prod", "aws_*")},
+			want:        []string{`aggregator "aws_all" references connection "aws_prod", which does not exist`},
+		},
+		{
+			name: "mixed plugins",
+			connections: []HCLBlock{
+				aggregator("aws_all", "aws", "aws_prod", "aws_dev"),
+				connection("aws_prod", "aws", ""),
+				connection("aws_dev", "aws@^0.80", `account "Dev" (222222222222)`),
+			},
+			want: []string{`account "Dev" (222222222222): connection "aws_dev" uses plugin "aws@^0.80", but aggregator "aws_all" uses "aws"`},
+		},
+	}
+
+	for _, test := range tests {
+		errs := ValidateConnections(test.connections, test.known)
+		if len(errs) != len(test.want) {
+			t.Errorf("%s: got errors %v, want %d", test.name, errs, len(test.want))
+			continue
+		}
+		for i, err := range errs {
+			if !strings.Contains(err.Error(), test.want[i]) {
+				t.Errorf("%s: got error %q, want one containing %q", test.name, err, test.want[i])
+			}
+		}
+	}
+}
+
+func TestShortenConnectionName(t *testing.T) {
+	short := "kubernetes_aws_123456789012_my_cluster"
+	if got := ShortenConnectionName(short); got != short {
+		t.Errorf("ShortenConnectionName(%q) = %q, want it unchanged", short, got)
+	}
+
+	long := "kubernetes_azure_" + strings.Repeat("long_subscription_", 3) + "my_cluster"
+	other := "kubernetes_azure_" + strings.Repeat("long_subscription_", 3) + "other_cluster"
+	got := ShortenConnectionName(long)
+	if err := ValidateConnectionName(got); err != nil {
+		t.Errorf("ShortenConnectionName(%q) = %q: %v", long, got, err)
+	}
+	if again := ShortenConnectionName(long); again != got {
+		t.Errorf("ShortenConnectionName is not deterministic: %q, then %q", got, again)
+	}
+	if ShortenConnectionName(other) == got {
+		t.Errorf("%q and %q are shortened to the same name %q", long, other, got)
+	}
+}
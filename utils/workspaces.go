@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/slices"
+)
+
+// AllCloudsWorkspace searches every top-level aggregator, such as aws_all and azure_all
+const AllCloudsWorkspace = "all-clouds"
+
+// allCloudsBlock names the block of workspaces.spc that is shared by every provider
+var allCloudsBlock = BlockName("steampipe", AllCloudsWorkspace)
+
+// topLevelAggregatorPattern matches the aggregators of every connection of a plugin
+var topLevelAggregatorPattern = regexp.MustCompile(`^[a-z0-9]+_all$`)
+
+// WorkspacesFilePath returns the Steampipe workspaces file
+func WorkspacesFilePath() string {
+	return filepath.Join(SteampipeConfigDir(), "workspaces.spc")
+}
+
+// Workspace builds a Steampipe workspace that searches the given connections first
+func Workspace(name string, searchPathPrefix []string) HCLBlock {
+	return HCLBlock{
+		Type:   "workspace",
+		Labels: []string{name},
+		Attributes: []HCLAttribute{
+			StringAttribute("search_path_prefix", strings.Join(searchPathPrefix, ",")),
+		},
+	}
+}
+
+// AggregatorWorkspaces builds a workspace for each aggregator, named like the aggregator with dashes,
+// such as aws-all for aws_all
+func AggregatorWorkspaces(connections []HCLBlock) []HCLBlock {
+	var workspaces []HCLBlock
+	for _, connection := range connections {
+		if isAggregator(connection) {
+			workspaces = append(workspaces, Workspace(strings.ReplaceAll(connection.Name(), "_", "-"), []string{connection.Name()}))
+		}
+	}
+	return workspaces
+}
+
+func isAggregator(block HCLBlock) bool {
+	connectionType, ok := block.Attribute("type")
+	return ok && connectionType.Type() == cty.String && !connectionType.IsNull() && connectionType.AsString() == "aggregator"
+}
+
+// plugin returns the plugin of a connection, or "" when it is not a string
+func plugin(block HCLBlock) string {
+	value, ok := block.Attribute("plugin")
+	if !ok || value.Type() != cty.String || value.IsNull() {
+		return ""
+	}
+	return value.AsString()
+}
+
+// UpdateWorkspaces writes the workspaces of the named block to workspaces.spc, and updates the shared
+// all-clouds workspace with the generated connections and the aggregators of the other Steampipe
+// config files. Workspaces are tuned with the performance profile unless it is nil.
+func UpdateWorkspaces(name string, workspaces []HCLBlock, connections []HCLBlock, profile *PerformanceProfile) error {
+	path := WorkspacesFilePath()
+
+	workspaces = profile.tune(workspaces)
+
+	section := "\n" + RenderHCL(workspaces)
+	if err := ValidateHCL(path, section); err != nil {
+		return err
+	}
+	if err := CreateOrReplaceInFile(path, name, section); err != nil {
+		return err
+	}
+
+	var aggregators []string
+	for _, connection := range connections {
+		if isAggregator(connection) && topLevelAggregatorPattern.MatchString(connection.Name()) {
+			aggregators = append(aggregators, connection.Name())
+		}
+	}
+	return UpdateAllCloudsWorkspace(aggregators, profile)
+}
+
+// UpdateAllCloudsWorkspace rewrites the all-clouds workspace to search every top-level aggregator in the
+// Steampipe config directory and the given aggregators, or removes it when there are none
+func UpdateAllCloudsWorkspace(aggregators []string, profile *PerformanceProfile) error {
+	existing, err := steampipeAggregators()
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	var searchPathPrefix []string
+	for _, aggregator := range append(existing, aggregators...) {
+		if !seen[aggregator] {
+			seen[aggregator] = true
+			searchPathPrefix = append(searchPathPrefix, aggregator)
+		}
+	}
+	sort.Strings(searchPathPrefix)
+
+	if len(searchPathPrefix) == 0 {
+		if _, err := os.Stat(WorkspacesFilePath()); os.IsNotExist(err) {
+			return nil
+		}
+		return RemoveBlocksFromFile(WorkspacesFilePath(), []string{allCloudsBlock})
+	}
+	workspaces := profile.tune([]HCLBlock{Workspace(AllCloudsWorkspace, searchPathPrefix)})
+	return CreateOrReplaceInFile(WorkspacesFilePath(), allCloudsBlock, "\n"+RenderHCL(workspaces))
+}
+
+// steampipeAggregators returns the top-level aggregators defined in the Steampipe config directory
+func steampipeAggregators() ([]string, error) {
+	connections, err := steampipeConfigConnections(WorkspacesFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	var aggregators []string
+	for _, block := range connections {
+		if !topLevelAggregatorPattern.MatchString(block.Labels[0]) {
+			continue
+		}
+		attribute, ok := block.Body.Attributes["type"]
+		if !ok {
+			continue
+		}
+		value, diags := attribute.Expr.Value(nil)
+		if !diags.HasErrors() && value.Type() == cty.String && !value.IsNull() && value.AsString() == "aggregator" {
+			aggregators = append(aggregators, block.Labels[0])
+		}
+	}
+	return aggregators, nil
+}
+
+// steampipeConfigConnections returns the connection blocks of the .spc files in the Steampipe config
+// directory, except for the excluded files. Files that cannot be parsed are skipped with a warning.
+func steampipeConfigConnections(exclude ...string) ([]*hclsyntax.Block, error) {
+	matches, err := filepath.Glob(filepath.Join(SteampipeConfigDir(), "*.spc"))
+	if err != nil {
+		return nil, err
+	}
+
+	var connections []*hclsyntax.Block
+	for _, match := range matches {
+		if slices.Contains(exclude, match) {
+			continue
+		}
+		contents, err := ioutil.ReadFile(match)
+		if err != nil {
+			return nil, err
+		}
+		file, diags := hclsyntax.ParseConfig(contents, match, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			fmt.Printf("Warning: skipping %s, which is not valid HCL: %v\n", match, diags)
+			continue
+		}
+		for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+			if block.Type == "connection" && len(block.Labels) > 0 {
+				connections = append(connections, block)
+			}
+		}
+	}
+	return connections, nil
+}
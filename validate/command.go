@@ -0,0 +1,8 @@
+package validate
+
+import "github.com/jessevdk/go-flags"
+
+func AddCommand(p *flags.Parser) {
+	p.AddCommand("validate", "Validate Steampipe config files",
+		"Check the connection names and aggregators of every .spc file in the Steampipe config directory", &struct{}{})
+}
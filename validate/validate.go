@@ -0,0 +1,23 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tamu-edu/aiphelper/utils"
+)
+
+func Init() {
+	files, connections, errs := utils.ValidateSteampipeConfigDir()
+
+	if len(errs) == 0 {
+		fmt.Printf("%d connections in %d files in %s are valid.\n", connections, files, utils.SteampipeConfigDir())
+		return
+	}
+
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	fmt.Printf("Found %d problems in %s.\n", len(errs), utils.SteampipeConfigDir())
+	os.Exit(1)
+}